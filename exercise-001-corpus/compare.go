@@ -0,0 +1,130 @@
+package main
+
+import (
+	"corpus/corpus"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// compareMain runs "word_count compare a b", which lists the words that
+// are unusually frequent in a compared with b and vice versa
+func compareMain(args []string) {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	analysis := addAnalysisFlags(fs)
+	format := fs.String("format", "table", "output `format` ("+strings.Join(outputFormats, ", ")+")")
+	top := fs.Int("top", 20, "print the `n` most over-represented words of each side (0 prints all)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: word_count compare [flags] <a> <b>")
+		fmt.Fprintln(fs.Output(), "Each side may be a file, a directory or a glob.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return
+	}
+	if !slices.Contains(outputFormats, *format) {
+		fatal(fmt.Sprintf("Unknown format %q, expected one of %s", *format, strings.Join(outputFormats, ", ")))
+	}
+
+	opts, err := analysis.options()
+	if err != nil {
+		fatal("Error:", err)
+	}
+
+	// Each side is analyzed on its own and merged into one histogram
+	var histograms [2]corpus.Histogram
+	var failed []fileResult
+	for i, arg := range fs.Args() {
+		paths, expandFailed := expandInputs([]string{arg})
+		results := analyzeFiles(paths, analysis.workers, analysis.input, false, opts)
+		histograms[i] = mergeResults(results)
+
+		failed = append(failed, expandFailed...)
+		for _, result := range results {
+			if result.Err != nil {
+				failed = append(failed, result)
+			}
+		}
+	}
+
+	report := corpus.Compare(histograms[0], histograms[1])
+	if *top > 0 {
+		report = report.Top(*top)
+	}
+	if err := writeKeyness(os.Stdout, *format, fs.Arg(0), fs.Arg(1), report); err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing output:", err)
+		os.Exit(1)
+	}
+	reportFailures(failed)
+}
+
+// writeKeyness writes both sides of a keyness report in the given format
+func writeKeyness(w io.Writer, format, nameA, nameB string, report corpus.KeynessReport) error {
+	sides := []struct {
+		Name  string           `json:"over"`
+		Words []corpus.Keyness `json:"words"`
+	}{
+		{nameA, report.OverA},
+		{nameB, report.OverB},
+	}
+
+	switch format {
+	case "json":
+		for i := range sides {
+			if sides[i].Words == nil {
+				sides[i].Words = []corpus.Keyness{}
+			}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(sides)
+
+	case "tsv", "csv":
+		writer := csv.NewWriter(w)
+		if format == "tsv" {
+			writer.Comma = '\t'
+		}
+		writer.Write([]string{"over", "word", "countA", "countB", "logLikelihood", "logRatio"})
+		for _, side := range sides {
+			for _, k := range side.Words {
+				writer.Write([]string{
+					side.Name, k.Word, strconv.Itoa(k.CountA), strconv.Itoa(k.CountB),
+					strconv.FormatFloat(k.LogLikelihood, 'f', 4, 64), strconv.FormatFloat(k.LogRatio, 'f', 4, 64),
+				})
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	// Table output, one block per side
+	for i, side := range sides {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "==> over-represented in %s <==\n", side.Name)
+
+		wordWidth := displayWidth("word")
+		for _, k := range side.Words {
+			wordWidth = max(wordWidth, displayWidth(k.Word))
+		}
+		fmt.Fprintf(w, "%s%s  %8s  %8s  %10s  %9s\n", "word", strings.Repeat(" ", wordWidth-4), "count a", "count b", "G2", "log ratio")
+		for _, k := range side.Words {
+			padding := strings.Repeat(" ", wordWidth-displayWidth(k.Word))
+			_, err := fmt.Fprintf(w, "%s%s  %8d  %8d  %10.2f  %9.2f\n", k.Word, padding, k.CountA, k.CountB, k.LogLikelihood, k.LogRatio)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package corpus
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// CollocationMeasure is a statistic used to rank collocations
+type CollocationMeasure int
+
+const (
+	// PMI is the pointwise mutual information, the binary log of how much
+	// more often a pair occurs than expected. It favours rare, exclusive pairs.
+	PMI CollocationMeasure = iota
+	// TScore measures the confidence that a pair is not due to chance. It
+	// favours frequent pairs.
+	TScore
+	// ChiSquare is Pearson's χ² test on the 2x2 table of the pair
+	ChiSquare
+)
+
+// Collocation is an ordered pair of words that occur near each other
+type Collocation struct {
+	First  string `json:"first"`
+	Second string `json:"second"`
+	Count  int    `json:"count"` // times Second followed First within the window
+
+	PMI       float64 `json:"pmi"`
+	TScore    float64 `json:"tScore"`
+	ChiSquare float64 `json:"chiSquare"`
+}
+
+// Collocations finds the pairs of words that occur together in r more often
+// than chance. Every word is paired with each of the window-1 words that
+// follow it, so a window of 2 pairs adjacent words only. Pairs seen fewer
+// than minCount times are left out, since the measures overrate rare pairs.
+// The result is ordered by the given measure, highest first. Stop words are
+// removed before pairing and stemming applies; WithNGrams is ignored.
+func Collocations(r io.Reader, window, minCount int, by CollocationMeasure, opts ...Option) ([]Collocation, error) {
+	if window < 2 {
+		return nil, fmt.Errorf("collocation window must be at least 2 words, got %d", window)
+	}
+	if by < PMI || by > ChiSquare {
+		return nil, fmt.Errorf("unknown collocation measure %d", by)
+	}
+	cfg := newConfig(opts)
+
+	type pair struct{ first, second string }
+	pairs := make(map[pair]int)
+	firsts := make(map[string]int)  // pairs starting with a word
+	seconds := make(map[string]int) // pairs ending with a word
+	total := 0
+
+	// The last window-1 words, each of which pairs with the next word
+	recent := make([]string, 0, window-1)
+	err := cfg.scanWords(r, stopWordFilter(cfg.stopWords, func(word string) {
+		term := cfg.term(word)
+		for _, first := range recent {
+			pairs[pair{first, term}]++
+			firsts[first]++
+			seconds[term]++
+			total++
+		}
+		if len(recent) == window-1 {
+			recent = append(recent[:0], recent[1:]...)
+		}
+		recent = append(recent, term)
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	var collocations []Collocation
+	for p, count := range pairs {
+		if count < minCount {
+			continue
+		}
+		c := Collocation{First: p.first, Second: p.second, Count: count}
+		c.PMI, c.TScore, c.ChiSquare = associate(count, firsts[p.first], seconds[p.second], total)
+		collocations = append(collocations, c)
+	}
+
+	score := func(c Collocation) float64 {
+		switch by {
+		case TScore:
+			return c.TScore
+		case ChiSquare:
+			return c.ChiSquare
+		}
+		return c.PMI
+	}
+	sort.Slice(collocations, func(i, j int) bool {
+		a, b := collocations[i], collocations[j]
+		if score(a) != score(b) {
+			return score(a) > score(b)
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.First != b.First {
+			return a.First < b.First
+		}
+		return a.Second < b.Second
+	})
+	return collocations, nil
+}
+
+// associate computes the association measures of a pair seen count times
+// among total pairs, where rowTotal pairs start with its first word and
+// colTotal pairs end with its second word
+func associate(count, rowTotal, colTotal, total int) (pmi, tScore, chiSquare float64) {
+	n := float64(total)
+	o11 := float64(count)
+	o12 := float64(rowTotal - count)
+	o21 := float64(colTotal - count)
+	o22 := n - o11 - o12 - o21
+	expected := float64(rowTotal) * float64(colTotal) / n
+
+	pmi = math.Log2(o11 / expected)
+	tScore = (o11 - expected) / math.Sqrt(o11)
+
+	// A word that starts or ends every pair leaves a margin of the table empty
+	denominator := (o11 + o12) * (o11 + o21) * (o12 + o22) * (o21 + o22)
+	if denominator > 0 {
+		chiSquare = n * math.Pow(o11*o22-o12*o21, 2) / denominator
+	}
+	return pmi, tScore, chiSquare
+}
@@ -0,0 +1,69 @@
+package corpus
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollocations(t *testing.T) {
+	text := "new york is big new york is old a new car"
+	collocations, err := Collocations(strings.NewReader(text), 2, 2, PMI)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(collocations))
+
+	// "is" follows "york" every time, which PMI ranks above "new york"
+	assert.Equal(t, "york", collocations[0].First)
+	assert.Equal(t, "is", collocations[0].Second)
+
+	newYork := collocations[1]
+	assert.Equal(t, Collocation{First: "new", Second: "york", Count: 2}, Collocation{First: newYork.First, Second: newYork.Second, Count: newYork.Count})
+	assert.InDelta(t, math.Log2(2/0.6), newYork.PMI, 1e-9)
+	assert.InDelta(t, 1.4/math.Sqrt(2), newYork.TScore, 1e-9)
+	assert.InDelta(t, 1960.0/336, newYork.ChiSquare, 1e-9)
+}
+
+func TestCollocationsWindow(t *testing.T) {
+	text := "strong black tea and strong green tea, strong sweet tea"
+	collocations, err := Collocations(strings.NewReader(text), 3, 3, TScore)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(collocations))
+	assert.Equal(t, "strong", collocations[0].First)
+	assert.Equal(t, "tea", collocations[0].Second)
+	assert.Equal(t, 3, collocations[0].Count)
+
+	// Stop words are removed before pairing, so "cup of tea" pairs "cup" and "tea"
+	collocations, err = Collocations(strings.NewReader("a cup of tea"), 2, 1, ChiSquare, WithStopWords(StopWords{"of": {}, "a": {}}))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(collocations))
+	assert.Equal(t, "cup", collocations[0].First)
+	assert.Equal(t, "tea", collocations[0].Second)
+}
+
+func TestCollocationsOrder(t *testing.T) {
+	text := "kick the bucket kick the bucket the end the end the end"
+	for _, by := range []CollocationMeasure{PMI, TScore, ChiSquare} {
+		collocations, err := Collocations(strings.NewReader(text), 2, 1, by)
+		assert.Nil(t, err)
+
+		score := func(c Collocation) float64 {
+			return []float64{c.PMI, c.TScore, c.ChiSquare}[by]
+		}
+		for i := 1; i < len(collocations); i++ {
+			assert.GreaterOrEqual(t, score(collocations[i-1]), score(collocations[i]))
+		}
+	}
+}
+
+func TestCollocationsErrors(t *testing.T) {
+	_, err := Collocations(strings.NewReader("x y"), 1, 1, PMI)
+	assert.NotNil(t, err)
+	_, err = Collocations(strings.NewReader("x y"), 2, 1, CollocationMeasure(7))
+	assert.NotNil(t, err)
+
+	collocations, err := Collocations(strings.NewReader(""), 2, 1, PMI)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(collocations))
+}
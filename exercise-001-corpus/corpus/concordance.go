@@ -0,0 +1,80 @@
+package corpus
+
+import (
+	"fmt"
+	"io"
+)
+
+// ConcordanceLine is one occurrence of a word together with the words
+// around it, also known as keyword in context (KWIC)
+type ConcordanceLine struct {
+	Left   []string `json:"left"`
+	Word   string   `json:"word"`
+	Right  []string `json:"right"`
+	Offset int64    `json:"offset"` // byte offset of the word in the text read
+	Line   int      `json:"line"`   // line number of the word, starting at 1
+
+	// SourceOffset is the byte offset of the word in the source the text
+	// was decoded from, or -1 if it is not known
+	SourceOffset int64 `json:"sourceOffset"`
+}
+
+// Concordance returns every occurrence of word in r with up to context
+// words on either side. The word is normalized with the same tokenizer and
+// stemming as the text, so with WithStemming "runs" also finds "running".
+// Stop words are kept so the context reads like the original text.
+//
+// Offsets and line numbers count the UTF-8 text read from r. If r is a
+// SourceMapper, like the output of a Decoder, source offsets come from it:
+// they point into the source file for text in any encoding, and are
+// unknown for markup such as HTML. Any other reader is its own source.
+func Concordance(r io.Reader, word string, context int, opts ...Option) ([]ConcordanceLine, error) {
+	cfg := newConfig(opts)
+	query := cfg.terms(word)
+	if len(query) != 1 {
+		return nil, fmt.Errorf("concordance needs a single word, got %q", word)
+	}
+	context = max(context, 0)
+	sourceOffset := func(offset int64) int64 { return offset }
+	if mapper, ok := r.(SourceMapper); ok {
+		sourceOffset = mapper.SourceOffset
+	}
+
+	var lines []ConcordanceLine
+	var left []string
+	open := 0 // lines at the end of lines still waiting for right context
+
+	err := cfg.tokenizer.Tokenize(r, func(token Token) {
+		// Fill the right context of earlier matches
+		for i := len(lines) - open; i < len(lines); i++ {
+			lines[i].Right = append(lines[i].Right, token.Text)
+			if len(lines[i].Right) == context {
+				open--
+			}
+		}
+
+		if cfg.term(token.Text) == query[0] {
+			lines = append(lines, ConcordanceLine{
+				Left:         append([]string{}, left...),
+				Word:         token.Text,
+				Right:        []string{},
+				Offset:       token.Offset,
+				Line:         token.Line,
+				SourceOffset: sourceOffset(token.Offset),
+			})
+			if context > 0 {
+				open++
+			}
+		}
+
+		// Keep only the last context words for the left side
+		left = append(left, token.Text)
+		if len(left) > context {
+			left = left[1:]
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
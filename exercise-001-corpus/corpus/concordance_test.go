@@ -0,0 +1,79 @@
+package corpus
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcordance(t *testing.T) {
+	text := "The old man.\nThe OLD sea and the old\nboat"
+	lines, err := Concordance(strings.NewReader(text), "Old", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []ConcordanceLine{
+		{Left: []string{"the"}, Word: "old", Right: []string{"man", "the"}, Offset: 4, Line: 1, SourceOffset: 4},
+		{Left: []string{"man", "the"}, Word: "old", Right: []string{"sea", "and"}, Offset: 17, Line: 2, SourceOffset: 17},
+		{Left: []string{"and", "the"}, Word: "old", Right: []string{"boat"}, Offset: 33, Line: 2, SourceOffset: 33},
+	}, lines)
+	assert.Equal(t, "old", strings.ToLower(text[lines[1].Offset:lines[1].Offset+3]))
+}
+
+func TestConcordanceDecoded(t *testing.T) {
+	// Offsets count the decoded text, source offsets the bytes of the source
+	prefix := strings.Repeat("plain ascii ", 400)
+	for _, c := range []struct {
+		name, source, word string
+		decoder            Decoder
+	}{
+		{"utf8.txt", "\xef\xbb\xbfcafé old\n", "old", Decoder{}},
+		{"latin1.txt", "caf\xe9 \xe9t\xe9 old\n", "old", Decoder{Encoding: EncodingLatin1}},
+		{"utf16.txt", "\xff\xfe" + string(utf16LE("café 😀 old\n")), string(utf16LE("old")), Decoder{}},
+		{"fallback.txt", prefix + "caf\xe9 old\n", "old", Decoder{}},
+	} {
+		text, err := c.decoder.Decode(strings.NewReader(c.source), c.name)
+		assert.Nil(t, err, c.name)
+		lines, err := Concordance(text, "old", 1)
+		assert.Nil(t, err, c.name)
+		if assert.Equal(t, 1, len(lines), c.name) {
+			offset := lines[0].SourceOffset
+			assert.NotEqual(t, lines[0].Offset, offset, c.name)
+			assert.Equal(t, c.word, c.source[offset:offset+int64(len(c.word))], c.name)
+		}
+	}
+
+	// Markup is gone, so the source offsets of HTML are unknown
+	text, err := Decode(strings.NewReader("<html><body><p><b>caf&eacute;</b> old</p></body></html>"), "page.html")
+	assert.Nil(t, err)
+	lines, err := Concordance(text, "old", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(6), lines[0].Offset)
+	assert.Equal(t, int64(-1), lines[0].SourceOffset)
+}
+
+func TestConcordanceStemming(t *testing.T) {
+	lines, err := Concordance(strings.NewReader("He runs. She was running."), "run", 0, WithStemming())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(lines))
+	assert.Equal(t, "running", lines[1].Word)
+	assert.Equal(t, 0, len(lines[1].Left))
+}
+
+func TestConcordanceSample(t *testing.T) {
+	file, err := os.Open("../7oldsamr.txt")
+	assert.Nil(t, err)
+	defer file.Close()
+
+	lines, err := Concordance(file, "samurai", 3)
+	assert.Nil(t, err)
+	assert.Equal(t, 6, len(lines))
+	assert.Equal(t, []string{"the", "seven", "old"}, lines[0].Left[len(lines[0].Left)-3:])
+}
+
+func TestConcordanceQuery(t *testing.T) {
+	_, err := Concordance(strings.NewReader("text"), "two words", 1)
+	assert.NotNil(t, err)
+	_, err = Concordance(strings.NewReader("text"), "!", 1)
+	assert.NotNil(t, err)
+}
@@ -1,51 +1,58 @@
 package corpus
 
 import (
-	"sort"
+	"io"
 	"strings"
-	"unicode"
 )
 
-type tKeyValPair struct {
-	Word  string
-	Count int
+func Analysis(textContent string, opts ...Option) Histogram {
+	// A strings.Reader never fails, so the error can be ignored
+	histogram, _ := AnalysisReader(strings.NewReader(textContent), opts...)
+	return histogram
 }
 
-func Analysis(textContent string) []tKeyValPair {
+// AnalysisReader builds the same histogram as Analysis, but tokenizes r
+// incrementally so the raw text is never held in memory
+func AnalysisReader(r io.Reader, opts ...Option) (Histogram, error) {
 	histogramMap := make(map[string]int)
-	var histogramSlice []tKeyValPair
 
-	// Split the text into words
-	words := splitIntoWords(textContent)
-
-	// Count each word in the histogram
-	for _, word := range words {
-		histogramMap[word]++
+	// Count each term in the histogram as it is read
+	err := scanTerms(r, newConfig(opts), func(term string) {
+		histogramMap[term]++
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// convert the resulting map into a slice
-	for word, count := range histogramMap {
-		histogramSlice = append(histogramSlice, tKeyValPair{Word: word, Count: count})
+	return newHistogram(histogramMap), nil
+}
+
+// scanTerms runs the words of r through the steps selected in cfg and
+// calls fn with every resulting term
+func scanTerms(r io.Reader, cfg *config, fn func(term string)) error {
+	if cfg.ngramSize <= 1 {
+		return cfg.scanWords(r, stopWordFilter(cfg.stopWords, func(word string) {
+			fn(cfg.term(word))
+		}))
 	}
 
-	// Sort the slice by value (frequency of word occurence)
-	sort.Slice(histogramSlice, func(i, j int) bool {
-		return histogramSlice[i].Count > histogramSlice[j].Count // Sort in descending order
+	// The words around a removed stop word or a sentence end were never
+	// next to each other, so no n-gram spans them
+	add, reset := ngramWindow(cfg.ngramSize, fn)
+	return cfg.scanSentenceWords(r, reset, func(word string) {
+		if cfg.stopWords.Contains(word) {
+			reset()
+			return
+		}
+		add(cfg.term(word))
 	})
-
-	return histogramSlice
 }
 
 // splits a string into words, filtering out punctuation and converting to lowercase
 func splitIntoWords(text string) []string {
-	// Use FieldsFunc to split at any non-letter character
-	words := strings.FieldsFunc(text, func(c rune) bool {
-		return !unicode.IsLetter(c)
+	var words []string
+	newConfig(nil).scanWords(strings.NewReader(text), func(word string) {
+		words = append(words, word)
 	})
-
-	// Convert words to lowercase
-	for i, word := range words {
-		words[i] = strings.ToLower(word)
-	}
 	return words
 }
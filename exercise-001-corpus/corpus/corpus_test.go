@@ -1,7 +1,11 @@
 package corpus
 
 import (
+	"bytes"
+	"os"
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -12,3 +16,33 @@ func TestCorpus(t *testing.T) {
 	assert.Equal(t, "you", result[0].Word)
 	assert.Equal(t, 2, result[0].Count)
 }
+
+func TestAnalysisReader(t *testing.T) {
+	content, err := os.ReadFile("../7oldsamr.txt")
+	assert.Nil(t, err)
+
+	expected := make(map[string]int)
+	for _, keyVal := range Analysis(string(content)) {
+		expected[keyVal.Word] = keyVal.Count
+	}
+
+	// Feed the text one byte at a time to exercise the incremental path
+	result, err := AnalysisReader(iotest.OneByteReader(bytes.NewReader(content)))
+	assert.Nil(t, err)
+
+	actual := make(map[string]int)
+	for _, keyVal := range result {
+		actual[keyVal.Word] = keyVal.Count
+	}
+	assert.Equal(t, expected, actual)
+	assert.Equal(t, 36, actual["the"])
+}
+
+func TestAnalysisReaderError(t *testing.T) {
+	_, err := AnalysisReader(iotest.TimeoutReader(strings.NewReader("one two three")))
+	assert.Equal(t, iotest.ErrTimeout, err)
+}
+
+func TestSplitIntoWords(t *testing.T) {
+	assert.Equal(t, []string{"don't", "panic", "42", "über"}, splitIntoWords("Don't PANIC, 42 Über!"))
+}
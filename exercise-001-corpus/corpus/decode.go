@@ -0,0 +1,279 @@
+package corpus
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Format is the kind of document a reader holds
+type Format string
+
+const (
+	FormatText     Format = "text"
+	FormatGzip     Format = "gzip"
+	FormatHTML     Format = "html"
+	FormatMarkdown Format = "markdown"
+	FormatEPUB     Format = "epub"
+)
+
+// Formats lists the formats Decode understands
+var Formats = []Format{FormatText, FormatGzip, FormatHTML, FormatMarkdown, FormatEPUB}
+
+// sniffLen is the number of leading bytes DetectFormat looks at
+const sniffLen = 512
+
+// DetectFormat guesses the format of a document from its file name and its
+// first bytes. Content signatures win over the file extension.
+func DetectFormat(name string, head []byte) Format {
+	switch {
+	case bytes.HasPrefix(head, []byte{0x1f, 0x8b}):
+		return FormatGzip
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")):
+		// An EPUB starts with an uncompressed "mimetype" entry
+		if bytes.Contains(head, []byte("application/epub+zip")) || strings.EqualFold(filepath.Ext(name), ".epub") {
+			return FormatEPUB
+		}
+	}
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".gz":
+		return FormatGzip
+	case ".epub":
+		return FormatEPUB
+	case ".html", ".htm", ".xhtml":
+		return FormatHTML
+	case ".md", ".markdown", ".mdown":
+		return FormatMarkdown
+	}
+
+	// Look for the start of an HTML document, skipping a BOM and space
+	lower := bytes.ToLower(bytes.TrimLeft(bytes.TrimPrefix(head, []byte("\xef\xbb\xbf")), " \t\r\n"))
+	for _, prefix := range []string{"<!doctype html", "<html", "<?xml"} {
+		if bytes.HasPrefix(lower, []byte(prefix)) && (prefix != "<?xml" || bytes.Contains(lower, []byte("<html"))) {
+			return FormatHTML
+		}
+	}
+	return FormatText
+}
+
+// Decoder turns documents into UTF-8 text. The zero value detects both
+// the format and the character encoding of every document.
+type Decoder struct {
+	Format   Format   // the format of the documents, or "" to detect it
+	Encoding Encoding // the encoding of text documents, or "" to detect it
+}
+
+// Decode detects the format of the document read from r and returns a
+// reader of its human-readable text. The name is only used for detection
+// and may be empty. Compressed documents are detected again once
+// decompressed, so "page.html.gz" yields the text of the page.
+func Decode(r io.Reader, name string) (io.Reader, error) {
+	return Decoder{}.Decode(r, name)
+}
+
+// DecodeFormat returns a reader of the human-readable text of a document
+// in a known format
+func DecodeFormat(r io.Reader, format Format) (io.Reader, error) {
+	return Decoder{Format: format}.Decode(r, "")
+}
+
+// Decode returns a reader of the human-readable text of the document read
+// from r, transcoded to UTF-8. The name is only used for detection.
+func (d Decoder) Decode(r io.Reader, name string) (io.Reader, error) {
+	br := bufio.NewReaderSize(r, sniffLen)
+	format := d.Format
+	if format == "" {
+		head, err := br.Peek(sniffLen)
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return nil, err
+		}
+		format = DetectFormat(name, head)
+	}
+	return d.decodeFormat(r, br, name, format)
+}
+
+// decodeFormat decodes br, which reads from r. The original reader is
+// kept so EPUB files can be read at random positions without a copy.
+func (d Decoder) decodeFormat(r io.Reader, br *bufio.Reader, name string, format Format) (io.Reader, error) {
+	var extract func(w io.Writer, r io.Reader) error
+	switch format {
+	case FormatText:
+		return Transcode(br, d.Encoding)
+	case FormatGzip:
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		text, err := Decoder{Encoding: d.Encoding}.Decode(zr, strings.TrimSuffix(name, filepath.Ext(name)))
+		if err != nil {
+			return nil, err
+		}
+		// Offsets in the decompressed text don't point into the file
+		return unmappedText{text}, nil
+	case FormatHTML:
+		extract = htmlText
+	case FormatMarkdown:
+		extract = markdownText
+	case FormatEPUB:
+		return decodeEPUB(r, br)
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+
+	text, err := Transcode(br, d.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	return pipeText(func(w io.Writer) error { return extract(w, text) }), nil
+}
+
+// pipeText runs extract in its own goroutine and returns a reader of the
+// text it writes, so large documents are converted as they are read
+func pipeText(extract func(w io.Writer) error) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(extract(pw))
+	}()
+	return unmappedText{pr}
+}
+
+// unmappedText is decoded text that can't be mapped back to its source,
+// because markup was dropped or the source was compressed
+type unmappedText struct {
+	io.Reader
+}
+
+// SourceOffset implements SourceMapper
+func (unmappedText) SourceOffset(offset int64) int64 {
+	return -1
+}
+
+// Close stops the decoder of the text, if it has one
+func (u unmappedText) Close() error {
+	if closer, ok := u.Reader.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// decodeEPUB extracts the text of the chapters of an EPUB in reading
+// order. A zip file needs random access, so unless r is a file the whole
+// archive is read into memory first.
+func decodeEPUB(r io.Reader, br *bufio.Reader) (io.Reader, error) {
+	var readerAt io.ReaderAt
+	var size int64
+	if file, ok := r.(*os.File); ok {
+		info, err := file.Stat()
+		if err != nil {
+			return nil, err
+		}
+		readerAt, size = file, info.Size()
+	} else {
+		content, err := io.ReadAll(br)
+		if err != nil {
+			return nil, err
+		}
+		readerAt, size = bytes.NewReader(content), int64(len(content))
+	}
+
+	archive, err := zip.NewReader(readerAt, size)
+	if err != nil {
+		return nil, err
+	}
+	chapters, err := epubChapters(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	return pipeText(func(w io.Writer) error {
+		for _, chapter := range chapters {
+			file, err := archive.Open(chapter)
+			if err != nil {
+				return err
+			}
+			// XHTML may be UTF-8 or UTF-16
+			text, err := Transcode(file, "")
+			if err == nil {
+				err = htmlText(w, text)
+			}
+			file.Close()
+			if err != nil {
+				return err
+			}
+			io.WriteString(w, "\n\n")
+		}
+		return nil
+	}), nil
+}
+
+// epubChapters returns the paths of the XHTML documents of an EPUB in
+// spine order. Without a readable package file, every HTML file in the
+// archive is used in name order.
+func epubChapters(archive *zip.Reader) ([]string, error) {
+	var container struct {
+		Rootfiles []struct {
+			FullPath string `xml:"full-path,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	var pkg struct {
+		Manifest []struct {
+			ID   string `xml:"id,attr"`
+			Href string `xml:"href,attr"`
+		} `xml:"manifest>item"`
+		Spine []struct {
+			IDRef string `xml:"idref,attr"`
+		} `xml:"spine>itemref"`
+	}
+
+	if readXML(archive, "META-INF/container.xml", &container) == nil && len(container.Rootfiles) > 0 {
+		opf := container.Rootfiles[0].FullPath
+		if readXML(archive, opf, &pkg) == nil && len(pkg.Spine) > 0 {
+			hrefs := make(map[string]string)
+			for _, item := range pkg.Manifest {
+				hrefs[item.ID] = item.Href
+			}
+
+			// Manifest paths are relative to the package file
+			var chapters []string
+			for _, ref := range pkg.Spine {
+				if href, ok := hrefs[ref.IDRef]; ok {
+					chapters = append(chapters, path.Join(path.Dir(opf), href))
+				}
+			}
+			return chapters, nil
+		}
+	}
+
+	var chapters []string
+	for _, file := range archive.File {
+		switch strings.ToLower(path.Ext(file.Name)) {
+		case ".xhtml", ".html", ".htm":
+			chapters = append(chapters, file.Name)
+		}
+	}
+	if len(chapters) == 0 {
+		return nil, fmt.Errorf("EPUB has no chapters")
+	}
+	sort.Strings(chapters)
+	return chapters, nil
+}
+
+// readXML decodes the XML file at name inside the archive into v
+func readXML(archive *zip.Reader, name string, v any) error {
+	file, err := archive.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return xml.NewDecoder(file).Decode(v)
+}
@@ -0,0 +1,124 @@
+package corpus
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectFormat(t *testing.T) {
+	assert.Equal(t, FormatGzip, DetectFormat("notes", []byte{0x1f, 0x8b, 8}))
+	assert.Equal(t, FormatEPUB, DetectFormat("book", []byte("PK\x03\x04....mimetypeapplication/epub+zip")))
+	assert.Equal(t, FormatHTML, DetectFormat("page.HTM", nil))
+	assert.Equal(t, FormatMarkdown, DetectFormat("README.md", []byte("# Title")))
+	assert.Equal(t, FormatHTML, DetectFormat("-", []byte("\n  <!DOCTYPE html><html>")))
+	assert.Equal(t, FormatText, DetectFormat("7oldsamr.txt", []byte("Seven old samurai")))
+	assert.Equal(t, FormatText, DetectFormat("", []byte("<b>not a document</b>")))
+}
+
+func TestDecodeHTML(t *testing.T) {
+	page := `<!DOCTYPE html>
+<html><head><title>Samurai</title>
+<style>p { color: red; }</style>
+<script>var robbers = "<p>bandits</p>";</script></head>
+<body><!-- hidden comment --><h1>Seven&nbsp;old samurai</h1><p class="x" title='a > b'>Fish &amp; chips<br>rice</p>
+<table><tr><td>one</td><td>two</td></tr></table></body></html>`
+	text := decodeString(t, strings.NewReader(page), "page.html")
+
+	assert.Equal(t, []string{"samurai", "seven", "old", "samurai", "fish", "chips", "rice", "one", "two"}, splitIntoWords(text))
+	assert.Contains(t, text, "Seven\u00a0old")
+	assert.Contains(t, text, "samurai\n\nFish")
+}
+
+func TestDecodeMarkdown(t *testing.T) {
+	doc := "# Seven *old* samurai #\n" +
+		"\n" +
+		"> A [story](http://example.com) about `code` and snake_case.\n" +
+		"\n" +
+		"```go\nfunc robbers() {}\n```\n" +
+		"\n" +
+		"    indented code\n" +
+		"\n" +
+		"- ![rice](rice.png) for __all__\n" +
+		"\n" +
+		"| a | b |\n|---|:-:|\n| one | two |\n" +
+		"***\n" +
+		"[story]: http://example.com\n"
+	text := decodeString(t, strings.NewReader(doc), "story.md")
+
+	assert.Equal(t, []string{
+		"seven", "old", "samurai", "a", "story", "about", "and", "snake_case",
+		"rice", "for", "all", "a", "b", "one", "two",
+	}, splitIntoWords(text))
+}
+
+func TestDecodeGzip(t *testing.T) {
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write([]byte("<p>Seven <b>old</b> samurai</p>"))
+	zw.Close()
+
+	// The name tells that the decompressed content is HTML
+	text := decodeString(t, bytes.NewReader(compressed.Bytes()), "page.html.gz")
+	assert.Equal(t, []string{"seven", "old", "samurai"}, splitIntoWords(text))
+
+	// A format given explicitly skips detection of the outer layer
+	r, err := DecodeFormat(bytes.NewReader(compressed.Bytes()), FormatGzip)
+	assert.Nil(t, err)
+	content, err := io.ReadAll(r)
+	assert.Nil(t, err)
+	assert.Equal(t, "<p>Seven <b>old</b> samurai</p>", string(content))
+
+	_, err = DecodeFormat(strings.NewReader("plain"), FormatGzip)
+	assert.NotNil(t, err)
+	_, err = DecodeFormat(strings.NewReader("plain"), Format("pdf"))
+	assert.NotNil(t, err)
+}
+
+func TestDecodeEPUB(t *testing.T) {
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	files := []struct{ name, content string }{
+		{"mimetype", "application/epub+zip"},
+		{"META-INF/container.xml", `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`},
+		{"OEBPS/content.opf", `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+  <manifest>
+    <item id="c1" href="text/one.xhtml" media-type="application/xhtml+xml"/>
+    <item id="c2" href="text/two.xhtml" media-type="application/xhtml+xml"/>
+    <item id="css" href="style.css" media-type="text/css"/>
+  </manifest>
+  <spine><itemref idref="c2"/><itemref idref="c1"/></spine>
+</package>`},
+		{"OEBPS/text/one.xhtml", `<html><body><p>robbers attacked</p></body></html>`},
+		{"OEBPS/text/two.xhtml", `<html><body><p>seven samurai</p></body></html>`},
+		{"OEBPS/style.css", `p { margin: 0 }`},
+	}
+	for _, file := range files {
+		w, err := zw.Create(file.name)
+		assert.Nil(t, err)
+		w.Write([]byte(file.content))
+	}
+	assert.Nil(t, zw.Close())
+
+	// Chapters follow the spine, not the names
+	text := decodeString(t, bytes.NewReader(archive.Bytes()), "book.epub")
+	assert.Equal(t, []string{"seven", "samurai", "robbers", "attacked"}, splitIntoWords(text))
+}
+
+// decodeString decodes r and returns all of its text
+func decodeString(t *testing.T, r io.Reader, name string) string {
+	decoded, err := Decode(r, name)
+	assert.Nil(t, err)
+	content, err := io.ReadAll(decoded)
+	assert.Nil(t, err)
+	return string(content)
+}
@@ -0,0 +1,380 @@
+package corpus
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding is a character encoding that text can be transcoded from
+type Encoding string
+
+const (
+	EncodingUTF8        Encoding = "utf-8"
+	EncodingUTF16LE     Encoding = "utf-16le"
+	EncodingUTF16BE     Encoding = "utf-16be"
+	EncodingUTF32LE     Encoding = "utf-32le"
+	EncodingUTF32BE     Encoding = "utf-32be"
+	EncodingLatin1      Encoding = "iso-8859-1"
+	EncodingWindows1252 Encoding = "windows-1252"
+)
+
+// Encodings lists the encodings Transcode understands
+var Encodings = []Encoding{
+	EncodingUTF8, EncodingUTF16LE, EncodingUTF16BE, EncodingUTF32LE, EncodingUTF32BE,
+	EncodingLatin1, EncodingWindows1252,
+}
+
+// encodingAliases maps other common names, without '-' and '_', to encodings
+var encodingAliases = map[string]Encoding{
+	"utf8": EncodingUTF8, "utf16le": EncodingUTF16LE, "utf16be": EncodingUTF16BE,
+	"utf32le": EncodingUTF32LE, "utf32be": EncodingUTF32BE,
+	"iso88591": EncodingLatin1, "latin1": EncodingLatin1, "l1": EncodingLatin1,
+	"windows1252": EncodingWindows1252, "cp1252": EncodingWindows1252,
+}
+
+// byteOrderMarks are checked in order, so UTF-32LE comes before UTF-16LE
+var byteOrderMarks = []struct {
+	bom      string
+	encoding Encoding
+}{
+	{"\xef\xbb\xbf", EncodingUTF8},
+	{"\xff\xfe\x00\x00", EncodingUTF32LE},
+	{"\x00\x00\xfe\xff", EncodingUTF32BE},
+	{"\xff\xfe", EncodingUTF16LE},
+	{"\xfe\xff", EncodingUTF16BE},
+}
+
+// windows1252 maps the bytes 0x80-0x9f, where Windows-1252 differs from
+// Latin-1. Zero marks the five bytes that are not assigned.
+var windows1252 = [32]rune{
+	'€', 0, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0, 'Ž', 0,
+	0, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0, 'ž', 'Ÿ',
+}
+
+// encodingSniffLen is the number of leading bytes DetectEncoding looks at
+const encodingSniffLen = 4096
+
+// maxSourceSegments bounds the memory a transcoder uses to map its output
+// back to its input when nobody asks, as when words are only counted
+const maxSourceSegments = 1 << 14
+
+// SourceMapper is implemented by readers of text decoded from a source,
+// such as the readers Decoder returns, that know where the text came from
+type SourceMapper interface {
+	// SourceOffset returns the byte offset in the source of the character
+	// at a byte offset in the decoded text, or -1 if it is not known.
+	// Offsets must be asked for in increasing order.
+	SourceOffset(offset int64) int64
+}
+
+// encodingFallbacks are used when a detected encoding turns out to be wrong
+// after the bytes it was detected from, as in a long ASCII file with a
+// single accented Latin-1 word near the end
+var encodingFallbacks = map[Encoding]Encoding{
+	EncodingUTF8:        EncodingWindows1252,
+	EncodingWindows1252: EncodingLatin1,
+}
+
+// DecodeError reports input that is not valid in its encoding
+type DecodeError struct {
+	Offset   int64 // byte offset of the invalid input
+	Encoding Encoding
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("invalid %s input at byte offset %d", e.Encoding, e.Offset)
+}
+
+// ParseEncoding returns the encoding with the given name. Case, '-' and '_'
+// are ignored, and common aliases such as "latin1" or "cp1252" are accepted.
+func ParseEncoding(name string) (Encoding, error) {
+	key := strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(name))
+	if encoding, ok := encodingAliases[key]; ok {
+		return encoding, nil
+	}
+	return "", fmt.Errorf("unknown encoding %q", name)
+}
+
+// DetectEncoding guesses the encoding of text from its first bytes. A byte
+// order mark decides; without one, the pattern of zero bytes reveals UTF-16
+// and UTF-32, and text that is not valid UTF-8 is taken for Windows-1252, or
+// Latin-1 if it uses bytes Windows-1252 does not assign.
+func DetectEncoding(head []byte) Encoding {
+	for _, mark := range byteOrderMarks {
+		if bytes.HasPrefix(head, []byte(mark.bom)) {
+			return mark.encoding
+		}
+	}
+
+	// Mostly ASCII text in UTF-16 or UTF-32 has zeros in the high bytes
+	var zeros [4]int
+	for i, b := range head {
+		if b == 0 {
+			zeros[i%4]++
+		}
+	}
+	quads, pairs := len(head)/4, len(head)/2
+	switch {
+	case quads > 0 && zeros[2] >= quads && zeros[3] >= quads && zeros[0] < quads:
+		return EncodingUTF32LE
+	case quads > 0 && zeros[0] >= quads && zeros[1] >= quads && zeros[3] < quads:
+		return EncodingUTF32BE
+	case pairs > 0 && zeros[1]+zeros[3] > pairs/2 && zeros[0]+zeros[2] < pairs/10:
+		return EncodingUTF16LE
+	case pairs > 0 && zeros[0]+zeros[2] > pairs/2 && zeros[1]+zeros[3] < pairs/10:
+		return EncodingUTF16BE
+	}
+
+	// The head may end in the middle of a character
+	for i := 0; i < len(head); {
+		c, size := utf8.DecodeRune(head[i:])
+		if c == utf8.RuneError && size <= 1 {
+			if utf8.FullRune(head[i:]) {
+				return detectSingleByte(head)
+			}
+			break
+		}
+		i += size
+	}
+	return EncodingUTF8
+}
+
+// detectSingleByte tells Windows-1252 from Latin-1 by the bytes 0x80-0x9f,
+// which are printable in the former and control characters in the latter
+func detectSingleByte(head []byte) Encoding {
+	for _, b := range head {
+		if b >= 0x80 && b < 0xa0 && windows1252[b-0x80] == 0 {
+			return EncodingLatin1
+		}
+	}
+	return EncodingWindows1252
+}
+
+// Transcode returns a reader of the text read from r converted from the
+// given encoding to UTF-8. An empty encoding is detected with
+// DetectEncoding. A byte order mark is removed. Reading fails with a
+// *DecodeError at the first input that is invalid in the encoding, unless
+// the encoding was detected: then text that is not valid UTF-8 after the
+// detected head is read as Windows-1252 from there on, and bytes
+// Windows-1252 does not assign as Latin-1.
+func Transcode(r io.Reader, encoding Encoding) (io.Reader, error) {
+	br := bufio.NewReaderSize(r, encodingSniffLen)
+	head, err := br.Peek(encodingSniffLen)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+
+	detected := encoding == ""
+	if detected {
+		encoding = DetectEncoding(head)
+	}
+	decode, ok := runeDecoders[encoding]
+	if !ok {
+		return nil, fmt.Errorf("unknown encoding %q", encoding)
+	}
+
+	t := &transcoder{r: br, encoding: encoding, decode: decode, detected: detected}
+	for _, mark := range byteOrderMarks {
+		if mark.encoding == encoding && bytes.HasPrefix(head, []byte(mark.bom)) {
+			br.Discard(len(mark.bom))
+			t.offset = int64(len(mark.bom))
+		}
+	}
+	return t, nil
+}
+
+// runeDecoders decode the first character of b in each encoding. They
+// return a size of 0 if b is too short and a negative size if b is invalid.
+var runeDecoders = map[Encoding]func(b []byte) (rune, int){
+	EncodingUTF8: func(b []byte) (rune, int) {
+		if !utf8.FullRune(b) {
+			return 0, 0
+		}
+		c, size := utf8.DecodeRune(b)
+		if c == utf8.RuneError && size == 1 {
+			return 0, -1
+		}
+		return c, size
+	},
+	EncodingUTF16LE: func(b []byte) (rune, int) {
+		return decodeUTF16(b, func(b []byte) uint16 { return uint16(b[0]) | uint16(b[1])<<8 })
+	},
+	EncodingUTF16BE: func(b []byte) (rune, int) {
+		return decodeUTF16(b, func(b []byte) uint16 { return uint16(b[0])<<8 | uint16(b[1]) })
+	},
+	EncodingUTF32LE: func(b []byte) (rune, int) {
+		if len(b) < 4 {
+			return 0, 0
+		}
+		return checkRune(rune(uint32(b[0])|uint32(b[1])<<8|uint32(b[2])<<16|uint32(b[3])<<24), 4)
+	},
+	EncodingUTF32BE: func(b []byte) (rune, int) {
+		if len(b) < 4 {
+			return 0, 0
+		}
+		return checkRune(rune(uint32(b[0])<<24|uint32(b[1])<<16|uint32(b[2])<<8|uint32(b[3])), 4)
+	},
+	EncodingLatin1: func(b []byte) (rune, int) {
+		return rune(b[0]), 1
+	},
+	EncodingWindows1252: func(b []byte) (rune, int) {
+		if b[0] < 0x80 || b[0] >= 0xa0 {
+			return rune(b[0]), 1
+		}
+		return checkRune(windows1252[b[0]-0x80], 1)
+	},
+}
+
+// decodeUTF16 decodes a character of one or two 16-bit units
+func decodeUTF16(b []byte, unit func([]byte) uint16) (rune, int) {
+	if len(b) < 2 {
+		return 0, 0
+	}
+	first := rune(unit(b))
+	if !utf16.IsSurrogate(first) {
+		return first, 2
+	}
+	if first >= 0xdc00 {
+		// A low surrogate can't come first
+		return 0, -1
+	}
+	if len(b) < 4 {
+		return 0, 0
+	}
+	c := utf16.DecodeRune(first, rune(unit(b[2:])))
+	if c == utf8.RuneError {
+		return 0, -1
+	}
+	return c, 4
+}
+
+// checkRune marks c as invalid if it is not a Unicode scalar value or is
+// zero, which the tables use for unassigned bytes
+func checkRune(c rune, size int) (rune, int) {
+	if c == 0 && size == 1 || !utf8.ValidRune(c) {
+		return 0, -1
+	}
+	return c, size
+}
+
+// transcoder is the reader returned by Transcode
+type transcoder struct {
+	r        io.Reader
+	encoding Encoding
+	decode   func(b []byte) (rune, int)
+	detected bool   // the encoding may fall back to another on invalid input
+	in       []byte // input not decoded yet
+	out      []byte // UTF-8 not returned yet
+	offset   int64  // input offset of in[0]
+	written  int64  // output offset of the end of out
+	err      error  // returned once out is drained
+
+	// segments are the runs of characters whose input and output sizes
+	// are in the same proportion, from which output offsets are mapped
+	// back to the input; UTF-8 input is a single run
+	segments []sourceSegment
+}
+
+// sourceSegment is a run of characters that each take inSize bytes of
+// input and outSize bytes of UTF-8, or the same number of bytes in both
+// if the sizes are 1
+type sourceSegment struct {
+	out, in         int64
+	outSize, inSize int64
+}
+
+// Read implements io.Reader
+func (t *transcoder) Read(p []byte) (int, error) {
+	for len(t.out) == 0 {
+		if t.err != nil {
+			return 0, t.err
+		}
+		t.fill()
+	}
+	n := copy(p, t.out)
+	t.out = t.out[n:]
+	return n, nil
+}
+
+// fill reads more input and decodes as much of it as possible
+func (t *transcoder) fill() {
+	var buf [4096]byte
+	n, err := t.r.Read(buf[:])
+	t.in = append(t.in, buf[:n]...)
+	t.out = t.out[:0]
+
+	i := 0
+	for i < len(t.in) {
+		c, size := t.decode(t.in[i:])
+		if size == 0 && err == io.EOF {
+			// The input ends in the middle of a character
+			size = -1
+		}
+		if size < 0 {
+			if t.fallBack() {
+				continue
+			}
+			t.err = &DecodeError{Offset: t.offset + int64(i), Encoding: t.encoding}
+			break
+		}
+		if size == 0 {
+			break
+		}
+		t.record(t.offset+int64(i), int64(size), int64(utf8.RuneLen(c)))
+		t.out = utf8.AppendRune(t.out, c)
+		i += size
+	}
+
+	t.in = t.in[i:]
+	t.offset += int64(i)
+	if t.err == nil && err != nil {
+		t.err = err
+	}
+}
+
+// record notes a character at an input offset for SourceOffset
+func (t *transcoder) record(in, inSize, outSize int64) {
+	segment := sourceSegment{out: t.written, in: in, outSize: outSize, inSize: inSize}
+	if inSize == outSize {
+		segment.outSize, segment.inSize = 1, 1
+	}
+	if n := len(t.segments); n == 0 || t.segments[n-1].inSize != segment.inSize || t.segments[n-1].outSize != segment.outSize {
+		t.segments = append(t.segments, segment)
+		if len(t.segments) > 2*maxSourceSegments {
+			t.segments = append(t.segments[:0], t.segments[len(t.segments)-maxSourceSegments:]...)
+		}
+	}
+	t.written += outSize
+}
+
+// SourceOffset implements SourceMapper. Only the most recent changes of
+// character sizes are remembered, which is far more than a tokenizer
+// reads ahead.
+func (t *transcoder) SourceOffset(offset int64) int64 {
+	i := sort.Search(len(t.segments), func(i int) bool {
+		return t.segments[i].out > offset
+	}) - 1
+	if i < 0 || offset >= t.written {
+		return -1
+	}
+	t.segments = t.segments[i:]
+	segment := t.segments[0]
+	return segment.in + (offset-segment.out)/segment.outSize*segment.inSize
+}
+
+// fallBack switches a detected encoding to its fallback, and reports
+// whether it did
+func (t *transcoder) fallBack() bool {
+	fallback, ok := encodingFallbacks[t.encoding]
+	if !t.detected || !ok {
+		return false
+	}
+	t.encoding, t.decode = fallback, runeDecoders[fallback]
+	return true
+}
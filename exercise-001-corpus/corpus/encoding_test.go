@@ -0,0 +1,173 @@
+package corpus
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectEncoding(t *testing.T) {
+	assert.Equal(t, EncodingUTF8, DetectEncoding([]byte("\xef\xbb\xbfsamurai")))
+	assert.Equal(t, EncodingUTF16LE, DetectEncoding([]byte("\xff\xfes\x00")))
+	assert.Equal(t, EncodingUTF16BE, DetectEncoding([]byte("\xfe\xff\x00s")))
+	assert.Equal(t, EncodingUTF32LE, DetectEncoding([]byte("\xff\xfe\x00\x00s\x00\x00\x00")))
+	assert.Equal(t, EncodingUTF32BE, DetectEncoding([]byte("\x00\x00\xfe\xff\x00\x00\x00s")))
+
+	// Without a byte order mark
+	assert.Equal(t, EncodingUTF16LE, DetectEncoding(utf16LE("seven samurai")))
+	assert.Equal(t, EncodingUTF16BE, DetectEncoding(utf16BE("seven samurai")))
+	assert.Equal(t, EncodingUTF32LE, DetectEncoding([]byte("s\x00\x00\x00a\x00\x00\x00")))
+	assert.Equal(t, EncodingUTF8, DetectEncoding([]byte("plain ascii")))
+	assert.Equal(t, EncodingUTF8, DetectEncoding([]byte("café \xc3")), "a character cut off at the end")
+	assert.Equal(t, EncodingWindows1252, DetectEncoding([]byte("caf\xe9 \x93quoted\x94")))
+	assert.Equal(t, EncodingLatin1, DetectEncoding([]byte("caf\xe9 \x81")))
+	assert.Equal(t, EncodingUTF8, DetectEncoding(nil))
+}
+
+func TestParseEncoding(t *testing.T) {
+	for name, want := range map[string]Encoding{
+		"UTF-8": EncodingUTF8, "utf_16le": EncodingUTF16LE, "latin1": EncodingLatin1,
+		"ISO-8859-1": EncodingLatin1, "cp1252": EncodingWindows1252, "utf-32be": EncodingUTF32BE,
+	} {
+		encoding, err := ParseEncoding(name)
+		assert.Nil(t, err, name)
+		assert.Equal(t, want, encoding, name)
+	}
+	_, err := ParseEncoding("ebcdic")
+	assert.NotNil(t, err)
+}
+
+func TestTranscode(t *testing.T) {
+	cases := []struct {
+		input    []byte
+		encoding Encoding
+	}{
+		{[]byte("\xef\xbb\xbfcafé “ok” 😀"), ""},
+		{append([]byte("\xff\xfe"), utf16LE("café “ok” 😀")...), ""},
+		{utf16BE("café “ok” 😀"), EncodingUTF16BE},
+		{[]byte("\x00\x00\xfe\xff\x00\x00\x00c\x00\x00\x00a\x00\x00\x00f\x00\x00\x00\xe9\x00\x00\x00 " +
+			"\x00\x00\x20\x1c\x00\x00\x00o\x00\x00\x00k\x00\x00\x20\x1d\x00\x00\x00 \x00\x01\xf6\x00"), ""},
+	}
+	for _, c := range cases {
+		assert.Equal(t, "café “ok” 😀", transcodeString(t, c.input, c.encoding))
+	}
+
+	assert.Equal(t, "café “ok”", transcodeString(t, []byte("caf\xe9 \x93ok\x94"), ""))
+	assert.Equal(t, "café \u0093ok\u0094", transcodeString(t, []byte("caf\xe9 \x93ok\x94"), EncodingLatin1))
+
+	// Reading one byte at a time splits characters across reads
+	r, err := Transcode(iotest.OneByteReader(strings.NewReader(string(utf16LE("seven 😀 samurai")))), EncodingUTF16LE)
+	assert.Nil(t, err)
+	content, err := io.ReadAll(r)
+	assert.Nil(t, err)
+	assert.Equal(t, "seven 😀 samurai", string(content))
+
+	_, err = Transcode(strings.NewReader("x"), Encoding("ebcdic"))
+	assert.NotNil(t, err)
+}
+
+func TestTranscodeError(t *testing.T) {
+	cases := []struct {
+		input    string
+		encoding Encoding
+		offset   int64
+		text     string
+	}{
+		{"seven \xff samurai", EncodingUTF8, 6, "seven "},
+		{"\xef\xbb\xbfok\xc3", EncodingUTF8, 5, "ok"},
+		{"o\x00\x00\xdck\x00", EncodingUTF16LE, 2, "o"},
+		{"\xfe\xff\x00o\x00", "", 4, "o"},
+		{"ok \x81", EncodingWindows1252, 3, "ok "},
+		{"\x00\x00\x11\x00", EncodingUTF32LE, 0, ""},
+	}
+	for _, c := range cases {
+		r, err := Transcode(strings.NewReader(c.input), c.encoding)
+		assert.Nil(t, err)
+		content, err := io.ReadAll(r)
+		assert.Equal(t, c.text, string(content), "%q", c.input)
+
+		var decodeErr *DecodeError
+		if assert.True(t, errors.As(err, &decodeErr), "%q", c.input) {
+			assert.Equal(t, c.offset, decodeErr.Offset, "%q", c.input)
+		}
+	}
+}
+
+func TestTranscodeFallback(t *testing.T) {
+	// The detected head is plain ASCII, so the Latin-1 words come after it
+	prefix := strings.Repeat("seven samurai ", 400)
+	assert.Equal(t, prefix+"café naïve", transcodeString(t, []byte(prefix+"caf\xe9 na\xefve"), ""))
+	assert.Equal(t, prefix+"“ok” \u0081", transcodeString(t, []byte(prefix+"\x93ok\x94 \x81"), ""))
+	assert.Equal(t, prefix+"café", transcodeString(t, []byte(prefix+"café"), ""))
+	assert.Equal(t, prefix+"Ã", transcodeString(t, []byte(prefix+"\xc3"), ""), "cut off at the end")
+
+	// An encoding that was asked for doesn't fall back
+	r, err := Transcode(strings.NewReader(prefix+"caf\xe9"), EncodingUTF8)
+	assert.Nil(t, err)
+	_, err = io.ReadAll(r)
+	var decodeErr *DecodeError
+	if assert.True(t, errors.As(err, &decodeErr)) {
+		assert.Equal(t, int64(len(prefix)+3), decodeErr.Offset)
+	}
+}
+
+func TestTranscodeSourceOffset(t *testing.T) {
+	// Every "é" takes one byte of Latin-1 and two of UTF-8
+	source := strings.Repeat("\xe9t\xe9 ", maxSourceSegments)
+	r, err := Transcode(strings.NewReader(source), EncodingLatin1)
+	assert.Nil(t, err)
+	text, err := io.ReadAll(r)
+	assert.Nil(t, err)
+	mapper := r.(SourceMapper)
+
+	// Only the most recent runs of character sizes are remembered
+	assert.Equal(t, int64(-1), mapper.SourceOffset(0))
+	last := int64(len(text) - len("été "))
+	assert.Equal(t, int64(len(source)-len("\xe9t\xe9 ")), mapper.SourceOffset(last))
+	assert.Equal(t, int64(len(source)-2), mapper.SourceOffset(last+3))
+	assert.Equal(t, int64(-1), mapper.SourceOffset(int64(len(text))))
+}
+
+func TestDecodeEncoding(t *testing.T) {
+	// HTML is transcoded before its tags are read
+	page := append([]byte("\xff\xfe"), utf16LE("<p>Café <b>société</b></p>")...)
+	assert.Equal(t, []string{"café", "société"}, splitIntoWords(decodeString(t, strings.NewReader(string(page)), "page.html")))
+
+	r, err := Decoder{Format: FormatText, Encoding: EncodingLatin1}.Decode(strings.NewReader("fa\xe7ade"), "")
+	assert.Nil(t, err)
+	content, err := io.ReadAll(r)
+	assert.Nil(t, err)
+	assert.Equal(t, "façade", string(content))
+}
+
+// transcodeString transcodes input and returns all of the text
+func transcodeString(t *testing.T, input []byte, encoding Encoding) string {
+	r, err := Transcode(strings.NewReader(string(input)), encoding)
+	assert.Nil(t, err)
+	content, err := io.ReadAll(r)
+	assert.Nil(t, err)
+	return string(content)
+}
+
+// utf16LE encodes s as UTF-16 in little-endian byte order
+func utf16LE(s string) []byte {
+	var b []byte
+	for _, unit := range utf16.Encode([]rune(s)) {
+		b = append(b, byte(unit), byte(unit>>8))
+	}
+	return b
+}
+
+// utf16BE encodes s as UTF-16 in big-endian byte order
+func utf16BE(s string) []byte {
+	var b []byte
+	for _, unit := range utf16.Encode([]rune(s)) {
+		b = append(b, byte(unit>>8), byte(unit))
+	}
+	return b
+}
@@ -0,0 +1,125 @@
+package corpus
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// KeyValPair is a single word and the number of times it occurred
+type KeyValPair struct {
+	Word  string `json:"word"`
+	Count int    `json:"count"`
+}
+
+// Histogram is a list of word counts ordered by descending count. Words
+// with the same count are ordered alphabetically so output is deterministic.
+type Histogram []KeyValPair
+
+// newHistogram converts a word->count map into a sorted Histogram
+func newHistogram(histogramMap map[string]int) Histogram {
+	histogram := make(Histogram, 0, len(histogramMap))
+	for word, count := range histogramMap {
+		histogram = append(histogram, KeyValPair{Word: word, Count: count})
+	}
+	histogram.sort()
+	return histogram
+}
+
+// sort orders the histogram by count (descending), then word (ascending)
+func (h Histogram) sort() {
+	sort.Slice(h, func(i, j int) bool {
+		if h[i].Count != h[j].Count {
+			return h[i].Count > h[j].Count
+		}
+		return h[i].Word < h[j].Word
+	})
+}
+
+// Map returns the histogram as a word->count map
+func (h Histogram) Map() map[string]int {
+	histogramMap := make(map[string]int, len(h))
+	for _, keyVal := range h {
+		histogramMap[keyVal.Word] += keyVal.Count
+	}
+	return histogramMap
+}
+
+// Total returns the sum of all counts in the histogram
+func (h Histogram) Total() int {
+	total := 0
+	for _, keyVal := range h {
+		total += keyVal.Count
+	}
+	return total
+}
+
+// TopN returns the n most frequent words. The whole histogram is returned
+// when n is negative or larger than its length.
+func (h Histogram) TopN(n int) Histogram {
+	if n < 0 || n > len(h) {
+		n = len(h)
+	}
+	return append(Histogram(nil), h[:n]...)
+}
+
+// Lookup returns the count of word and whether it is in the histogram
+func (h Histogram) Lookup(word string) (int, bool) {
+	for _, keyVal := range h {
+		if keyVal.Word == word {
+			return keyVal.Count, true
+		}
+	}
+	return 0, false
+}
+
+// Merge returns a new histogram with the counts of h and others added together
+func (h Histogram) Merge(others ...Histogram) Histogram {
+	histogramMap := h.Map()
+	for _, other := range others {
+		for _, keyVal := range other {
+			histogramMap[keyVal.Word] += keyVal.Count
+		}
+	}
+	return newHistogram(histogramMap)
+}
+
+// Subtract returns a new histogram with the counts of other taken away from
+// h. Words whose count drops to zero or below are removed.
+func (h Histogram) Subtract(other Histogram) Histogram {
+	histogramMap := h.Map()
+	for _, keyVal := range other {
+		if _, ok := histogramMap[keyVal.Word]; !ok {
+			continue
+		}
+		histogramMap[keyVal.Word] -= keyVal.Count
+		if histogramMap[keyVal.Word] <= 0 {
+			delete(histogramMap, keyVal.Word)
+		}
+	}
+	return newHistogram(histogramMap)
+}
+
+// Filter returns the entries of h for which keep returns true, in the same order
+func (h Histogram) Filter(keep func(KeyValPair) bool) Histogram {
+	var filtered Histogram
+	for _, keyVal := range h {
+		if keep(keyVal) {
+			filtered = append(filtered, keyVal)
+		}
+	}
+	return filtered
+}
+
+// MinLength is a Filter predicate keeping words of at least n characters
+func MinLength(n int) func(KeyValPair) bool {
+	return func(keyVal KeyValPair) bool {
+		return utf8.RuneCountInString(keyVal.Word) >= n
+	}
+}
+
+// MinCount is a Filter predicate keeping words that occurred at least n times
+func MinCount(n int) func(KeyValPair) bool {
+	return func(keyVal KeyValPair) bool {
+		return keyVal.Count >= n
+	}
+}
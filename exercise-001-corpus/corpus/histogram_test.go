@@ -0,0 +1,52 @@
+package corpus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogramOrder(t *testing.T) {
+	result := Analysis("b a c b a d")
+	assert.Equal(t, Histogram{{"a", 2}, {"b", 2}, {"c", 1}, {"d", 1}}, result)
+}
+
+func TestHistogramTopN(t *testing.T) {
+	h := Analysis("b a c b a d")
+	assert.Equal(t, Histogram{{"a", 2}, {"b", 2}}, h.TopN(2))
+	assert.Equal(t, h, h.TopN(10))
+	assert.Equal(t, h, h.TopN(-1))
+	assert.Equal(t, 0, len(h.TopN(0)))
+}
+
+func TestHistogramLookup(t *testing.T) {
+	h := Analysis("b a c b a d")
+	count, ok := h.Lookup("b")
+	assert.True(t, ok)
+	assert.Equal(t, 2, count)
+
+	_, ok = h.Lookup("z")
+	assert.False(t, ok)
+	assert.Equal(t, 6, h.Total())
+}
+
+func TestHistogramMergeSubtract(t *testing.T) {
+	a := Analysis("one two two three")
+	b := Analysis("two three three four")
+
+	assert.Equal(t, Histogram{{"three", 3}, {"two", 3}, {"four", 1}, {"one", 1}}, a.Merge(b))
+	assert.Equal(t, Histogram{{"one", 1}, {"two", 1}}, a.Subtract(b))
+	assert.Equal(t, Histogram{{"three", 5}, {"two", 4}, {"four", 2}, {"one", 1}}, a.Merge(b, b))
+
+	// The operands are left untouched
+	assert.Equal(t, Histogram{{"two", 2}, {"one", 1}, {"three", 1}}, a)
+}
+
+func TestHistogramFilter(t *testing.T) {
+	h := Analysis("a bb bb ccc ccc ccc dddd")
+	assert.Equal(t, Histogram{{"ccc", 3}, {"dddd", 1}}, h.Filter(MinLength(3)))
+	assert.Equal(t, Histogram{{"ccc", 3}, {"bb", 2}}, h.Filter(MinCount(2)))
+	assert.Equal(t, Histogram{{"ccc", 3}}, h.Filter(func(keyVal KeyValPair) bool {
+		return MinLength(3)(keyVal) && MinCount(2)(keyVal)
+	}))
+}
@@ -0,0 +1,210 @@
+package corpus
+
+import (
+	"bufio"
+	"bytes"
+	"html"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// maxEntityLen is the longest character reference htmlText tries to decode
+const maxEntityLen = 32
+
+// skippedElements hold content that is not human-readable text
+var skippedElements = map[string]bool{
+	"script": true, "style": true, "template": true, "svg": true, "math": true,
+}
+
+// blockElements start a new paragraph, so words on either side don't run together
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "body": true,
+	"caption": true, "dd": true, "details": true, "dialog": true, "div": true, "dl": true,
+	"dt": true, "fieldset": true, "figcaption": true, "figure": true, "footer": true,
+	"form": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"head": true, "header": true, "hr": true, "html": true, "li": true, "main": true,
+	"nav": true, "ol": true, "option": true, "p": true, "pre": true, "section": true,
+	"summary": true, "table": true, "tbody": true, "tfoot": true, "thead": true,
+	"title": true, "tr": true, "ul": true,
+}
+
+// htmlText copies the readable text of an HTML document from r to w. Tags,
+// comments, and the content of scripts and styles are left out, and
+// character references like "&amp;" are decoded. Block elements such as
+// paragraphs are separated by blank lines.
+func htmlText(w io.Writer, r io.Reader) error {
+	br := bufio.NewReader(r)
+	bw := bufio.NewWriter(w)
+	skip := ""   // the element whose content is being skipped
+	breaks := "" // separator owed before the next text, so empty elements don't pile up blank lines
+	started := false
+
+	// text writes s after any pending separator
+	text := func(s string) {
+		if started {
+			bw.WriteString(breaks)
+		}
+		breaks = ""
+		started = true
+		bw.WriteString(s)
+	}
+
+	for {
+		c, _, err := br.ReadRune()
+		if err == io.EOF {
+			return bw.Flush()
+		}
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case c == '<' && skip != "":
+			// Only the matching end tag ends a skipped element
+			next, _ := br.Peek(len(skip) + 1)
+			if strings.EqualFold(string(next), "/"+skip) {
+				if _, err := readTag(br); err != nil {
+					return err
+				}
+				skip = ""
+			}
+
+		case skip != "":
+
+		case c == '<':
+			next, err := br.Peek(1)
+			if err != nil || !(next[0] == '/' || next[0] == '!' || next[0] == '?' || isASCIILetter(next[0])) {
+				// A lone '<' is text
+				text("<")
+				continue
+			}
+
+			if next[0] == '!' {
+				if err := skipMarkup(br); err != nil {
+					return err
+				}
+				continue
+			}
+
+			tag, err := readTag(br)
+			if err != nil {
+				return err
+			}
+			name, closing := tagName(tag)
+			switch {
+			case skippedElements[name] && !closing && !strings.HasSuffix(tag, "/"):
+				skip = name
+			case name == "br" && breaks != "\n\n":
+				breaks += "\n"
+			case (name == "td" || name == "th") && breaks == "":
+				breaks = " "
+			case blockElements[name]:
+				breaks = "\n\n"
+			}
+
+		case c == '&':
+			text(readEntity(br))
+
+		case unicode.IsSpace(c) && (breaks != "" || !started):
+			// A separator is already owed, so the source layout adds nothing
+
+		default:
+			text(string(c))
+		}
+	}
+}
+
+// readTag reads the rest of a tag after its '<', up to and excluding the
+// closing '>'. A '>' inside a quoted attribute value does not end the tag.
+func readTag(br *bufio.Reader) (string, error) {
+	var tag strings.Builder
+	var quote byte
+	for {
+		c, err := br.ReadByte()
+		if err == io.EOF {
+			return tag.String(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return tag.String(), nil
+		}
+		tag.WriteByte(c)
+	}
+}
+
+// tagName returns the lowercase name of a tag read by readTag and whether
+// it is an end tag
+func tagName(tag string) (string, bool) {
+	closing := strings.HasPrefix(tag, "/")
+	tag = strings.TrimPrefix(tag, "/")
+	end := strings.IndexFunc(tag, func(c rune) bool {
+		return unicode.IsSpace(c) || c == '/'
+	})
+	if end >= 0 {
+		tag = tag[:end]
+	}
+	return strings.ToLower(tag), closing
+}
+
+// skipMarkup skips a comment, CDATA section or declaration after its '<'
+func skipMarkup(br *bufio.Reader) error {
+	end := []byte(">")
+	if head, _ := br.Peek(3); string(head) == "!--" {
+		end = []byte("-->")
+	} else if head, _ := br.Peek(8); string(head) == "![CDATA[" {
+		end = []byte("]]>")
+	}
+
+	var window []byte
+	for {
+		c, err := br.ReadByte()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		window = append(window, c)
+		if len(window) > len(end) {
+			window = window[1:]
+		}
+		if bytes.Equal(window, end) {
+			return nil
+		}
+	}
+}
+
+// readEntity reads a character reference after its '&' and returns the
+// decoded text. Anything that is not a known reference is returned as is.
+func readEntity(br *bufio.Reader) string {
+	raw := []byte{'&'}
+	for len(raw) < maxEntityLen {
+		next, err := br.Peek(1)
+		if err != nil || !(isASCIILetter(next[0]) || (next[0] >= '0' && next[0] <= '9') || next[0] == '#') {
+			break
+		}
+		br.ReadByte()
+		raw = append(raw, next[0])
+	}
+	if next, err := br.Peek(1); err == nil && next[0] == ';' {
+		br.ReadByte()
+		raw = append(raw, ';')
+	}
+	return html.UnescapeString(string(raw))
+}
+
+// isASCIILetter reports whether c is a letter in a-z or A-Z
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
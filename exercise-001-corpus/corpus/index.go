@@ -0,0 +1,435 @@
+package corpus
+
+import (
+	"bufio"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// indexMagic and indexVersion start every index file
+const (
+	indexMagic   = "corpus-index"
+	indexVersion = 1
+)
+
+// Posting lists the positions of a term in one document. Positions count
+// the words of the document from 0, including stop words that are not indexed.
+type Posting struct {
+	Doc       int
+	Positions []int
+}
+
+// Index is a positional inverted index stored in a file. It maps every term
+// to the documents and positions where it occurs, and answers boolean and
+// phrase queries. Documents are tokenized like Analysis does, so the same
+// options must be passed every time an index file is opened.
+type Index struct {
+	path  string
+	cfg   *config
+	docs  []string
+	names map[string]int
+	terms map[string][]Posting
+}
+
+// indexFile is the on-disk form of an Index
+type indexFile struct {
+	Magic   string
+	Version int
+	Docs    []string
+	Terms   map[string][]Posting
+}
+
+// OpenIndex loads the index stored at path, or starts an empty one if the
+// file does not exist yet. Save writes it back to the same path.
+func OpenIndex(path string, opts ...Option) (*Index, error) {
+	ix := &Index{
+		path:  path,
+		cfg:   newConfig(opts),
+		names: make(map[string]int),
+		terms: make(map[string][]Posting),
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ix, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var stored indexFile
+	if err := gob.NewDecoder(bufio.NewReader(file)).Decode(&stored); err != nil {
+		return nil, fmt.Errorf("reading index %s: %w", path, err)
+	}
+	if stored.Magic != indexMagic || stored.Version != indexVersion {
+		return nil, fmt.Errorf("%s is not a version %d corpus index", path, indexVersion)
+	}
+
+	ix.docs = stored.Docs
+	for id, name := range ix.docs {
+		ix.names[name] = id
+	}
+	if stored.Terms != nil {
+		ix.terms = stored.Terms
+	}
+	return ix, nil
+}
+
+// Save writes the index to its file. The file is replaced atomically, so a
+// failed save leaves the previous version intact.
+func (ix *Index) Save() error {
+	return writeFileAtomic(ix.path, func(w io.Writer) error {
+		return gob.NewEncoder(w).Encode(indexFile{
+			Magic:   indexMagic,
+			Version: indexVersion,
+			Docs:    ix.docs,
+			Terms:   ix.terms,
+		})
+	})
+}
+
+// writeFileAtomic replaces the file at path with the output of write. The
+// output goes to a temporary file first that is renamed when complete.
+func writeFileAtomic(path string, write func(w io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	err = write(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Add tokenizes the document read from r and adds it to the index under
+// name. It returns the ID of the new document.
+func (ix *Index) Add(name string, r io.Reader) (int, error) {
+	if _, ok := ix.names[name]; ok {
+		return 0, fmt.Errorf("document %q is already indexed", name)
+	}
+
+	// Collect the positions of every term before touching the index, so a
+	// read error doesn't leave a half-indexed document behind
+	positions := make(map[string][]int)
+	position := 0
+	err := ix.cfg.scanWords(r, func(word string) {
+		if !ix.cfg.stopWords.Contains(word) {
+			term := ix.cfg.term(word)
+			positions[term] = append(positions[term], position)
+		}
+		position++
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	id := len(ix.docs)
+	ix.docs = append(ix.docs, name)
+	ix.names[name] = id
+	for term, list := range positions {
+		ix.terms[term] = append(ix.terms[term], Posting{Doc: id, Positions: list})
+	}
+	return id, nil
+}
+
+// Len returns the number of indexed documents
+func (ix *Index) Len() int {
+	return len(ix.docs)
+}
+
+// Name returns the name of the document with the given ID
+func (ix *Index) Name(id int) string {
+	return ix.docs[id]
+}
+
+// Postings returns the postings of a word, normalized like indexed text
+func (ix *Index) Postings(word string) []Posting {
+	terms := ix.cfg.terms(word)
+	if len(terms) != 1 {
+		return nil
+	}
+	return ix.terms[terms[0]]
+}
+
+// Search returns the names of the documents matching query, in the order
+// they were added. Queries combine words and quoted phrases with AND, OR,
+// NOT and parentheses; words next to each other must all match:
+//
+//	samurai AND (robbers OR bandits) NOT "old man"
+func (ix *Index) Search(query string) ([]string, error) {
+	p := &queryParser{ix: ix, tokens: lexQuery(query)}
+	docs, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in query", p.tokens[p.pos])
+	}
+
+	names := make([]string, len(docs))
+	for i, id := range docs {
+		names[i] = ix.docs[id]
+	}
+	return names, nil
+}
+
+// phrase returns the documents containing text as consecutive words. Stop
+// words in the phrase match any word, since they were not indexed.
+func (ix *Index) phrase(text string) ([]int, error) {
+	var words []string
+	ix.cfg.scanWords(strings.NewReader(text), func(word string) {
+		words = append(words, word)
+	})
+
+	// Look up the postings of every indexed word with its offset in the phrase
+	type part struct {
+		offset   int
+		postings []Posting
+	}
+	var parts []part
+	for offset, word := range words {
+		if !ix.cfg.stopWords.Contains(word) {
+			parts = append(parts, part{offset, ix.terms[ix.cfg.term(word)]})
+		}
+	}
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("query %q has no indexed words", text)
+	}
+
+	// Only documents containing every word can contain the phrase
+	candidates := postingDocs(parts[0].postings)
+	for _, p := range parts[1:] {
+		candidates = intersectDocs(candidates, postingDocs(p.postings))
+	}
+
+	var docs []int
+	for _, doc := range candidates {
+		// Start positions that line up for every word seen so far
+		starts := make(map[int]bool)
+		for _, position := range findPosting(parts[0].postings, doc).Positions {
+			starts[position-parts[0].offset] = true
+		}
+		for _, p := range parts[1:] {
+			next := make(map[int]bool)
+			for _, position := range findPosting(p.postings, doc).Positions {
+				if starts[position-p.offset] {
+					next[position-p.offset] = true
+				}
+			}
+			starts = next
+		}
+		if len(starts) > 0 {
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
+// postingDocs returns the document IDs of a posting list
+func postingDocs(postings []Posting) []int {
+	docs := make([]int, len(postings))
+	for i, posting := range postings {
+		docs[i] = posting.Doc
+	}
+	return docs
+}
+
+// findPosting returns the posting of doc, which must be in the list
+func findPosting(postings []Posting, doc int) Posting {
+	i := sort.Search(len(postings), func(i int) bool { return postings[i].Doc >= doc })
+	return postings[i]
+}
+
+// intersectDocs returns the IDs found in both sorted lists
+func intersectDocs(a, b []int) []int {
+	var docs []int
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			docs = append(docs, a[i])
+			i++
+			j++
+		}
+	}
+	return docs
+}
+
+// unionDocs returns the IDs found in either sorted list
+func unionDocs(a, b []int) []int {
+	var docs []int
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] < b[j]:
+			docs = append(docs, a[i])
+			i++
+		case a[i] > b[j]:
+			docs = append(docs, b[j])
+			j++
+		default:
+			docs = append(docs, a[i])
+			i++
+			j++
+		}
+	}
+	docs = append(docs, a[i:]...)
+	return append(docs, b[j:]...)
+}
+
+// subtractDocs returns the IDs of a that are not in b
+func subtractDocs(a, b []int) []int {
+	var docs []int
+	j := 0
+	for _, doc := range a {
+		for j < len(b) && b[j] < doc {
+			j++
+		}
+		if j == len(b) || b[j] != doc {
+			docs = append(docs, doc)
+		}
+	}
+	return docs
+}
+
+// lexQuery splits a query into words, quoted phrases and parentheses.
+// Phrases keep their quotes so the parser can tell them apart.
+func lexQuery(query string) []string {
+	var tokens []string
+	for i := 0; i < len(query); {
+		switch c := query[i]; {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '"':
+			end := strings.IndexByte(query[i+1:], '"')
+			if end < 0 {
+				end = len(query) - i - 1
+			}
+			tokens = append(tokens, query[i:i+1+end]+`"`)
+			i += end + 2
+		default:
+			end := strings.IndexAny(query[i:], " \t\n()\"")
+			if end < 0 {
+				end = len(query) - i
+			}
+			tokens = append(tokens, query[i:i+end])
+			i += end
+		}
+	}
+	return tokens
+}
+
+// queryParser evaluates a lexed query with recursive descent:
+//
+//	or      = and { "OR" and }
+//	and     = not { [ "AND" ] not }
+//	not     = "NOT" not | primary
+//	primary = "(" or ")" | phrase | word
+type queryParser struct {
+	ix     *Index
+	tokens []string
+	pos    int
+}
+
+// peek returns the next token, or "" at the end of the query
+func (p *queryParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *queryParser) parseOr() ([]int, error) {
+	docs, err := p.parseAnd()
+	for err == nil && p.peek() == "OR" {
+		p.pos++
+		var more []int
+		more, err = p.parseAnd()
+		docs = unionDocs(docs, more)
+	}
+	return docs, err
+}
+
+func (p *queryParser) parseAnd() ([]int, error) {
+	docs, err := p.parseNot()
+	for err == nil {
+		switch p.peek() {
+		case "AND":
+			p.pos++
+		case "", "OR", ")":
+			return docs, nil
+		}
+		var more []int
+		more, err = p.parseNot()
+		docs = intersectDocs(docs, more)
+	}
+	return docs, err
+}
+
+func (p *queryParser) parseNot() ([]int, error) {
+	if p.peek() != "NOT" {
+		return p.parsePrimary()
+	}
+	p.pos++
+	docs, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	all := make([]int, len(p.ix.docs))
+	for i := range all {
+		all[i] = i
+	}
+	return subtractDocs(all, docs), nil
+}
+
+func (p *queryParser) parsePrimary() ([]int, error) {
+	token := p.peek()
+	p.pos++
+
+	switch {
+	case token == "":
+		return nil, errors.New("unexpected end of query")
+	case token == "(":
+		docs, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, errors.New("missing ) in query")
+		}
+		p.pos++
+		return docs, nil
+	case token == ")" || token == "AND" || token == "OR":
+		return nil, fmt.Errorf("unexpected %q in query", token)
+	case strings.HasPrefix(token, `"`):
+		return p.ix.phrase(strings.Trim(token, `"`))
+	}
+
+	// A single word may still split into several, like "e-mail"
+	return p.ix.phrase(token)
+}
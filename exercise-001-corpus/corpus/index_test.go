@@ -0,0 +1,103 @@
+package corpus
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestIndex(t *testing.T, path string, opts ...Option) *Index {
+	ix, err := OpenIndex(path, opts...)
+	assert.Nil(t, err)
+	for _, doc := range []struct{ name, text string }{
+		{"samurai", "The seven old samurai fought the robbers."},
+		{"emperor", "The old emperor sent for the samurai."},
+		{"robbers", "The robbers lived in a cave on the mountain."},
+	} {
+		_, err := ix.Add(doc.name, strings.NewReader(doc.text))
+		assert.Nil(t, err)
+	}
+	return ix
+}
+
+func TestIndexSearch(t *testing.T) {
+	ix := newTestIndex(t, filepath.Join(t.TempDir(), "corpus.idx"))
+
+	cases := map[string][]string{
+		"samurai":                      {"samurai", "emperor"},
+		"Samurai AND robbers":          {"samurai"},
+		"samurai robbers":              {"samurai"},
+		"emperor OR cave":              {"emperor", "robbers"},
+		"NOT samurai":                  {"robbers"},
+		"the NOT (emperor OR seven)":   {"robbers"},
+		`"old samurai"`:                {"samurai"},
+		`"samurai old"`:                {},
+		`"old emperor sent" OR "cave"`: {"emperor", "robbers"},
+		"dragon":                       {},
+	}
+	for query, expected := range cases {
+		docs, err := ix.Search(query)
+		assert.Nil(t, err, query)
+		assert.Equal(t, expected, docs, query)
+	}
+}
+
+func TestIndexQueryErrors(t *testing.T) {
+	ix := newTestIndex(t, filepath.Join(t.TempDir(), "corpus.idx"))
+	for _, query := range []string{"", "samurai AND", "(samurai", "samurai )", "OR cave", "!!"} {
+		_, err := ix.Search(query)
+		assert.NotNil(t, err, query)
+	}
+}
+
+func TestIndexPostings(t *testing.T) {
+	ix := newTestIndex(t, filepath.Join(t.TempDir(), "corpus.idx"))
+	assert.Equal(t, []Posting{{Doc: 0, Positions: []int{0, 5}}, {Doc: 1, Positions: []int{0, 5}}, {Doc: 2, Positions: []int{0, 7}}}, ix.Postings("THE"))
+	assert.Equal(t, 3, ix.Len())
+	assert.Equal(t, "emperor", ix.Name(1))
+
+	_, err := ix.Add("emperor", strings.NewReader("again"))
+	assert.NotNil(t, err)
+}
+
+func TestIndexPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "corpus.idx")
+	ix := newTestIndex(t, path)
+	assert.Nil(t, ix.Save())
+
+	// Reopen the index and add a document incrementally
+	ix, err := OpenIndex(path)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, ix.Len())
+	_, err = ix.Add("village", strings.NewReader("The samurai guarded the village."))
+	assert.Nil(t, err)
+	assert.Nil(t, ix.Save())
+
+	ix, err = OpenIndex(path)
+	assert.Nil(t, err)
+	docs, err := ix.Search("samurai NOT old")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"village"}, docs)
+
+	// Files that are not an index are rejected
+	assert.Nil(t, os.WriteFile(path, []byte("not an index"), 0644))
+	_, err = OpenIndex(path)
+	assert.NotNil(t, err)
+}
+
+func TestIndexOptions(t *testing.T) {
+	english, _ := BuiltinStopWords("en")
+	ix := newTestIndex(t, filepath.Join(t.TempDir(), "corpus.idx"), WithStopWords(english), WithStemming())
+
+	assert.Nil(t, ix.Postings("the"))
+	docs, err := ix.Search(`"samurais fought the robber"`)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"samurai"}, docs)
+
+	docs, err = ix.Search(`"seven the samurai"`)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"samurai"}, docs)
+}
@@ -0,0 +1,122 @@
+package corpus
+
+import (
+	"math"
+	"sort"
+)
+
+// Keyness measures how unusually frequent a word is in corpus A compared
+// with corpus B
+type Keyness struct {
+	Word   string `json:"word"`
+	CountA int    `json:"countA"`
+	CountB int    `json:"countB"`
+
+	// LogLikelihood is the G² statistic. It measures how certain the
+	// difference is; values above 3.84 are significant at p < 0.05.
+	LogLikelihood float64 `json:"logLikelihood"`
+
+	// LogRatio is the binary log of the ratio of relative frequencies. It
+	// measures the size of the difference; +1 means twice as frequent in A.
+	LogRatio float64 `json:"logRatio"`
+}
+
+// KeynessReport holds the words over-represented in either corpus,
+// ordered by descending log-likelihood
+type KeynessReport struct {
+	OverA []Keyness `json:"overA"`
+	OverB []Keyness `json:"overB"`
+}
+
+// Compare computes the keyness of every word in a and b. Words with the
+// same relative frequency in both corpora are left out.
+func Compare(a, b Histogram) KeynessReport {
+	totalA, totalB := a.Total(), b.Total()
+	countsA, countsB := a.Map(), b.Map()
+
+	// Every word of either corpus is compared
+	words := make(map[string]struct{}, len(countsA)+len(countsB))
+	for word := range countsA {
+		words[word] = struct{}{}
+	}
+	for word := range countsB {
+		words[word] = struct{}{}
+	}
+
+	var report KeynessReport
+	for word := range words {
+		k := Keyness{Word: word, CountA: countsA[word], CountB: countsB[word]}
+		k.LogLikelihood = logLikelihood(k.CountA, k.CountB, totalA, totalB)
+		k.LogRatio = logRatio(k.CountA, k.CountB, totalA, totalB)
+
+		switch {
+		case k.LogRatio > 0:
+			report.OverA = append(report.OverA, k)
+		case k.LogRatio < 0:
+			report.OverB = append(report.OverB, k)
+		}
+	}
+
+	sortKeyness(report.OverA)
+	sortKeyness(report.OverB)
+	return report
+}
+
+// Top returns a report with at most n words on either side. The whole
+// report is returned when n is negative, like Histogram.TopN.
+func (r KeynessReport) Top(n int) KeynessReport {
+	if n < 0 {
+		return r
+	}
+	return KeynessReport{
+		OverA: r.OverA[:min(n, len(r.OverA))],
+		OverB: r.OverB[:min(n, len(r.OverB))],
+	}
+}
+
+// logLikelihood computes G² for a word seen a times in totalA words and b
+// times in totalB words
+func logLikelihood(a, b, totalA, totalB int) float64 {
+	if totalA == 0 || totalB == 0 {
+		return 0
+	}
+
+	expectedA := float64(totalA) * float64(a+b) / float64(totalA+totalB)
+	expectedB := float64(totalB) * float64(a+b) / float64(totalA+totalB)
+
+	g2 := 0.0
+	if a > 0 {
+		g2 += float64(a) * math.Log(float64(a)/expectedA)
+	}
+	if b > 0 {
+		g2 += float64(b) * math.Log(float64(b)/expectedB)
+	}
+	return 2 * g2
+}
+
+// logRatio computes log2 of the ratio of relative frequencies. A zero
+// count is replaced by 0.5 so words missing on one side stay finite.
+func logRatio(a, b, totalA, totalB int) float64 {
+	if totalA == 0 || totalB == 0 {
+		return 0
+	}
+
+	countA, countB := float64(a), float64(b)
+	if a == 0 {
+		countA = 0.5
+	}
+	if b == 0 {
+		countB = 0.5
+	}
+	return math.Log2((countA / float64(totalA)) / (countB / float64(totalB)))
+}
+
+// sortKeyness orders by descending log-likelihood, then alphabetically
+func sortKeyness(keyness []Keyness) {
+	sort.Slice(keyness, func(i, j int) bool {
+		if keyness[i].LogLikelihood != keyness[j].LogLikelihood {
+			return keyness[i].LogLikelihood > keyness[j].LogLikelihood
+		}
+		return keyness[i].Word < keyness[j].Word
+	})
+}
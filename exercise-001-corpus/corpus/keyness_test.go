@@ -0,0 +1,57 @@
+package corpus
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	a := Analysis(strings.Repeat("samurai robbers the the ", 10))
+	b := Analysis(strings.Repeat("emperor palace the the ", 10))
+
+	report := Compare(a, b)
+	assert.Equal(t, []string{"robbers", "samurai"}, keynessWords(report.OverA))
+	assert.Equal(t, []string{"emperor", "palace"}, keynessWords(report.OverB))
+
+	// "the" has the same relative frequency on both sides
+	for _, k := range append(report.OverA, report.OverB...) {
+		assert.NotEqual(t, "the", k.Word)
+	}
+
+	robbers := report.OverA[0]
+	assert.Equal(t, 10, robbers.CountA)
+	assert.Equal(t, 0, robbers.CountB)
+	assert.InDelta(t, math.Log2((10.0/40)/(0.5/40)), robbers.LogRatio, 1e-9)
+	assert.True(t, robbers.LogLikelihood > 3.84)
+	assert.True(t, report.OverB[0].LogRatio < 0)
+}
+
+func TestLogLikelihood(t *testing.T) {
+	// 10 in 1000 words against 2 in 1000 words
+	assert.InDelta(t, 5.8221, logLikelihood(10, 2, 1000, 1000), 1e-4)
+	assert.Equal(t, 0.0, logLikelihood(5, 5, 100, 100))
+	assert.Equal(t, 0.0, logLikelihood(1, 0, 10, 0))
+}
+
+func TestKeynessReportTop(t *testing.T) {
+	report := Compare(Analysis("a a b c"), Analysis("d d e"))
+	top := report.Top(1)
+	assert.Equal(t, []string{"a"}, keynessWords(top.OverA))
+	assert.Equal(t, []string{"d"}, keynessWords(top.OverB))
+	assert.Equal(t, 3, len(report.OverA))
+
+	assert.Equal(t, report, report.Top(-1))
+	assert.Empty(t, report.Top(0).OverA)
+	assert.Empty(t, report.Top(0).OverB)
+}
+
+func keynessWords(keyness []Keyness) []string {
+	var words []string
+	for _, k := range keyness {
+		words = append(words, k.Word)
+	}
+	return words
+}
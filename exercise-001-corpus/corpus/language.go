@@ -0,0 +1,162 @@
+package corpus
+
+import (
+	"embed"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+//go:embed languages/*.txt
+var languageSamples embed.FS
+
+const (
+	// languageProfileSize is the number of top n-grams compared, as
+	// suggested by Cavnar and Trenkle
+	languageProfileSize = 300
+
+	// maxLanguageGram is the length of the longest n-grams in a profile
+	maxLanguageGram = 5
+
+	// languageSampleLen is how much of a reader DetectLanguageReader uses
+	languageSampleLen = 1 << 16
+)
+
+// LanguageGuess is a language a text may be written in
+type LanguageGuess struct {
+	Language string `json:"language"` // ISO 639-1 code, like "en"
+
+	// Confidence is between 0 and 1. It grows with the margin to the next
+	// closest language, so a text that two languages fit equally well gets 0.
+	Confidence float64 `json:"confidence"`
+
+	// Distance is the Cavnar-Trenkle out-of-place measure between the
+	// text and the language profile; lower is closer
+	Distance int `json:"distance"`
+}
+
+// languageProfiles maps every language to the ranks of its top n-grams,
+// built from the embedded sample texts the first time they are needed
+var languageProfiles = sync.OnceValue(func() map[string]map[string]int {
+	profiles := make(map[string]map[string]int)
+	entries, _ := languageSamples.ReadDir("languages")
+	for _, entry := range entries {
+		sample, _ := languageSamples.ReadFile("languages/" + entry.Name())
+		language := strings.TrimSuffix(entry.Name(), path.Ext(entry.Name()))
+		profiles[language] = gramRanks(ngramProfile(string(sample)))
+	}
+	return profiles
+})
+
+// Languages returns the codes of the languages that can be detected
+func Languages() []string {
+	var languages []string
+	for language := range languageProfiles() {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// DetectLanguage returns the most likely language of text. The language
+// is empty if text has no letters.
+func DetectLanguage(text string) LanguageGuess {
+	guesses := RankLanguages(text)
+	if len(guesses) == 0 {
+		return LanguageGuess{}
+	}
+	return guesses[0]
+}
+
+// DetectLanguageReader detects the language of the text read from r. Only
+// the first 64 KiB are used, which is plenty for a reliable guess.
+func DetectLanguageReader(r io.Reader) (LanguageGuess, error) {
+	sample, err := io.ReadAll(io.LimitReader(r, languageSampleLen))
+	if err != nil {
+		return LanguageGuess{}, err
+	}
+	return DetectLanguage(string(sample)), nil
+}
+
+// RankLanguages compares the character n-grams of text with the profile
+// of every language (Cavnar and Trenkle, "N-Gram-Based Text
+// Categorization", 1994) and returns the languages from closest to
+// farthest. Only the best guess has a confidence. Texts without letters
+// return no guesses.
+func RankLanguages(text string) []LanguageGuess {
+	profile := ngramProfile(text)
+	if len(profile) == 0 {
+		return nil
+	}
+
+	var guesses []LanguageGuess
+	for language, ranks := range languageProfiles() {
+		distance := 0
+		for rank, gram := range profile {
+			if languageRank, ok := ranks[gram]; ok {
+				distance += abs(rank - languageRank)
+			} else {
+				distance += languageProfileSize
+			}
+		}
+		guesses = append(guesses, LanguageGuess{Language: language, Distance: distance})
+	}
+	sort.Slice(guesses, func(i, j int) bool {
+		if guesses[i].Distance != guesses[j].Distance {
+			return guesses[i].Distance < guesses[j].Distance
+		}
+		return guesses[i].Language < guesses[j].Language
+	})
+
+	// The confidence is the gap to the runner-up, relative to the largest
+	// gap possible for a text of this size
+	if len(guesses) > 1 {
+		gap := guesses[1].Distance - guesses[0].Distance
+		guesses[0].Confidence = min(1, 4*float64(gap)/float64(len(profile)*languageProfileSize))
+	}
+	return guesses
+}
+
+// ngramProfile returns the most frequent character n-grams of text, from
+// 1 to maxLanguageGram characters, most frequent first. Words are
+// lowercased and padded with '_' so the n-grams capture their start and end.
+func ngramProfile(text string) []string {
+	counts := make(map[string]int)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(c rune) bool { return !unicode.IsLetter(c) }) {
+		runes := []rune("_" + word + "_")
+		for n := 1; n <= maxLanguageGram; n++ {
+			for i := 0; i+n <= len(runes); i++ {
+				gram := string(runes[i : i+n])
+				if gram != "_" {
+					counts[gram]++
+				}
+			}
+		}
+	}
+
+	grams := newHistogram(counts).TopN(languageProfileSize)
+	profile := make([]string, len(grams))
+	for i, gram := range grams {
+		profile[i] = gram.Word
+	}
+	return profile
+}
+
+// gramRanks maps the n-grams of a profile to their rank
+func gramRanks(profile []string) map[string]int {
+	ranks := make(map[string]int, len(profile))
+	for rank, gram := range profile {
+		ranks[gram] = rank
+	}
+	return ranks
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,63 @@
+package corpus
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	sentences := map[string]string{
+		"en": "The quick brown fox jumps over the lazy dog while the children watch from the window.",
+		"de": "Der schnelle braune Fuchs springt über den faulen Hund, während die Kinder zuschauen.",
+		"fr": "Le renard brun rapide saute par-dessus le chien paresseux pendant que les enfants regardent.",
+		"es": "El rápido zorro marrón salta sobre el perro perezoso mientras los niños miran.",
+		"it": "La volpe marrone veloce salta sopra il cane pigro mentre i bambini guardano.",
+		"pt": "A raposa marrom rápida pula sobre o cão preguiçoso enquanto as crianças olham.",
+		"nl": "De snelle bruine vos springt over de luie hond terwijl de kinderen kijken.",
+		"sv": "Den snabba bruna räven hoppar över den lata hunden medan barnen tittar.",
+		"da": "Den hurtige brune ræv springer over den dovne hund, mens børnene kigger.",
+		"fi": "Nopea ruskea kettu hyppää laiskan koiran yli, kun lapset katsovat.",
+		"pl": "Szybki brązowy lis przeskakuje nad leniwym psem, a dzieci patrzą.",
+		"cs": "Rychlá hnědá liška skáče přes líného psa, zatímco se děti dívají.",
+		"tr": "Hızlı kahverengi tilki tembel köpeğin üzerinden atlarken çocuklar izliyor.",
+		"ru": "Быстрая коричневая лиса прыгает через ленивую собаку, пока дети смотрят.",
+		"hu": "A gyors barna róka átugrik a lusta kutya felett, miközben a gyerekek nézik.",
+		"el": "Η γρήγορη καφέ αλεπού πηδά πάνω από τον τεμπέλη σκύλο ενώ τα παιδιά κοιτάζουν.",
+	}
+	assert.Equal(t, len(sentences), len(Languages()))
+
+	for language, sentence := range sentences {
+		guess := DetectLanguage(sentence)
+		assert.Equal(t, language, guess.Language, sentence)
+		assert.Greater(t, guess.Confidence, 0.0, sentence)
+		assert.LessOrEqual(t, guess.Confidence, 1.0, sentence)
+	}
+
+	assert.Equal(t, LanguageGuess{}, DetectLanguage("42 !!!"))
+}
+
+func TestRankLanguages(t *testing.T) {
+	guesses := RankLanguages("Alle Menschen sind frei und gleich an Würde und Rechten geboren.")
+	assert.Equal(t, len(Languages()), len(guesses))
+	assert.Equal(t, "de", guesses[0].Language)
+	for i := 1; i < len(guesses); i++ {
+		assert.LessOrEqual(t, guesses[i-1].Distance, guesses[i].Distance)
+		assert.Equal(t, 0.0, guesses[i].Confidence)
+	}
+}
+
+func TestDetectLanguageReader(t *testing.T) {
+	file, err := os.Open("../7oldsamr.txt")
+	assert.Nil(t, err)
+	defer file.Close()
+
+	guess, err := DetectLanguageReader(file)
+	assert.Nil(t, err)
+	assert.Equal(t, "en", guess.Language)
+
+	// A longer text is told apart with more confidence than a short one
+	short := DetectLanguage("seven old samurai")
+	assert.Greater(t, guess.Confidence, short.Confidence)
+}
@@ -0,0 +1,232 @@
+package corpus
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultCategory is the category of lexicon entries that only give a
+	// weight, like the words of a sentiment lexicon
+	DefaultCategory = "score"
+
+	// negationScope is the number of words after a negation whose weights
+	// in DefaultCategory are inverted, so "not very good" counts against
+	// "good"
+	negationScope = 3
+)
+
+// Lexicon maps words to the categories they belong to and their weight
+// in each, for example "refund" to "billing" or "awful" to a score of -3
+type Lexicon map[string]map[string]float64
+
+// negations are the words that invert the weights of the words after them.
+// Contractions ending in "n't" are negations as well.
+var negations = map[string]bool{
+	"not": true, "no": true, "never": true, "none": true, "nobody": true,
+	"nothing": true, "neither": true, "nor": true, "without": true,
+	"cannot": true, "hardly": true,
+}
+
+// LoadLexicon reads a lexicon from the file at path
+func LoadLexicon(path string) (Lexicon, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadLexicon(file)
+}
+
+// ReadLexicon reads a lexicon from r. Every line has a word followed by a
+// category, a weight, or a category and a weight, separated by tabs or
+// spaces:
+//
+//	refund    billing
+//	awful     -3
+//	crash     bug      2
+//
+// A category without a weight has weight 1, and a weight without a
+// category is in DefaultCategory. A word may be listed under several
+// categories. Everything after a '#' on a line is a comment. Lines split
+// by tabs may have entries of several words, which are never matched, so
+// lexicons with a few phrases can still be used.
+func ReadLexicon(r io.Reader) (Lexicon, error) {
+	lexicon := make(Lexicon)
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if strings.Contains(line, "\t") {
+			fields = nil
+			for _, field := range strings.Split(line, "\t") {
+				if field = strings.TrimSpace(field); field != "" {
+					fields = append(fields, field)
+				}
+			}
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		word, category, weight := strings.ToLower(fields[0]), DefaultCategory, 1.0
+		switch len(fields) {
+		case 2:
+			if w, err := strconv.ParseFloat(fields[1], 64); err == nil {
+				weight = w
+			} else {
+				category = fields[1]
+			}
+		case 3:
+			w, err := strconv.ParseFloat(fields[2], 64)
+			if err != nil {
+				return nil, fmt.Errorf("lexicon line %d: invalid weight %q", lineNumber, fields[2])
+			}
+			category, weight = fields[1], w
+		default:
+			return nil, fmt.Errorf("lexicon line %d: expected a word and a category, a weight or both", lineNumber)
+		}
+
+		if lexicon[word] == nil {
+			lexicon[word] = make(map[string]float64)
+		}
+		lexicon[word][category] = weight
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return lexicon, nil
+}
+
+// DocumentScore is the score of a document in every category of a
+// lexicon that any of its words belongs to
+type DocumentScore struct {
+	Words      int             `json:"words"` // all words, to normalize scores by length
+	Categories []CategoryScore `json:"categories"`
+}
+
+// CategoryScore is the sum of the weights of the words of a document in a
+// category, and the words that contributed to it
+type CategoryScore struct {
+	Category string      `json:"category"`
+	Score    float64     `json:"score"`
+	Words    []WordScore `json:"words"`
+}
+
+// WordScore is the contribution of one word to a category score
+type WordScore struct {
+	Word    string  `json:"word"`
+	Count   int     `json:"count"`
+	Negated int     `json:"negated"` // occurrences whose weight was inverted
+	Score   float64 `json:"score"`
+}
+
+// Score adds up the weights of the words of r in every category. Words are
+// made into terms as AnalysisReader makes them, and so are the words of the
+// lexicon, so with WithStemming "refunds" matches a "refund" entry. Stop
+// words are not scored. A negation such as "not" or "isn't" inverts the
+// weights in DefaultCategory of the next three words in the same sentence;
+// other categories say what a text is about rather than how it judges it,
+// so "no refund" still counts toward billing. WithNGrams is ignored. Categories are ordered by descending score, and their words by
+// descending contribution.
+func (l Lexicon) Score(r io.Reader, opts ...Option) (DocumentScore, error) {
+	cfg := newConfig(opts)
+
+	// Look the lexicon up by term; when words share a term, the weights of
+	// the last one in alphabetical order are used
+	words := make([]string, 0, len(l))
+	for word := range l {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	byTerm := make(map[string]map[string]float64, len(l))
+	for _, word := range words {
+		if terms := cfg.terms(word); len(terms) == 1 {
+			byTerm[terms[0]] = l[word]
+		}
+	}
+
+	type wordCategory struct{ term, category string }
+	contributions := make(map[wordCategory]*WordScore)
+	var score DocumentScore
+	negated, previous := 0, ""
+
+	scoreWord := func(word string) {
+		score.Words++
+		lower, before := strings.ToLower(word), previous
+		previous = lower
+
+		// Split contractions leave "n't" as a "t" after a word ending in "n"
+		if negations[lower] || strings.HasSuffix(lower, "n't") || lower == "t" && strings.HasSuffix(before, "n") {
+			negated = negationScope
+			return
+		}
+		inScope := negated > 0
+		negated = max(negated-1, 0)
+
+		if cfg.stopWords.Contains(word) {
+			return
+		}
+		term := cfg.term(word)
+		for category, weight := range byTerm[term] {
+			key := wordCategory{term, category}
+			contribution := contributions[key]
+			if contribution == nil {
+				contribution = &WordScore{Word: term}
+				contributions[key] = contribution
+			}
+			contribution.Count++
+			if inScope && category == DefaultCategory {
+				contribution.Negated++
+				weight = -weight
+			}
+			contribution.Score += weight
+		}
+	}
+
+	// A negation never reaches into the next sentence
+	err := cfg.scanSentenceWords(r, func() {
+		negated, previous = 0, ""
+	}, scoreWord)
+	if err != nil {
+		return DocumentScore{}, err
+	}
+
+	categories := make(map[string]*CategoryScore)
+	for key, contribution := range contributions {
+		category := categories[key.category]
+		if category == nil {
+			category = &CategoryScore{Category: key.category}
+			categories[key.category] = category
+		}
+		category.Score += contribution.Score
+		category.Words = append(category.Words, *contribution)
+	}
+	for _, category := range categories {
+		sort.Slice(category.Words, func(i, j int) bool {
+			a, b := category.Words[i], category.Words[j]
+			if math.Abs(a.Score) != math.Abs(b.Score) {
+				return math.Abs(a.Score) > math.Abs(b.Score)
+			}
+			return a.Word < b.Word
+		})
+		score.Categories = append(score.Categories, *category)
+	}
+	sort.Slice(score.Categories, func(i, j int) bool {
+		a, b := score.Categories[i], score.Categories[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.Category < b.Category
+	})
+	return score, nil
+}
@@ -0,0 +1,109 @@
+package corpus
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testLexicon = `# support ticket categories
+refund    billing
+invoice   billing
+charge    billing   2
+crash     bug       3
+good      2
+great     3
+awful     -3
+slow      -1
+can't stand	-3	# phrases are read but not matched
+`
+
+func mustScore(t *testing.T, lexicon Lexicon, text string, opts ...Option) DocumentScore {
+	t.Helper()
+	score, err := lexicon.Score(strings.NewReader(text), opts...)
+	assert.Nil(t, err)
+	return score
+}
+
+func TestReadLexicon(t *testing.T) {
+	lexicon, err := ReadLexicon(strings.NewReader(testLexicon))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]float64{"billing": 1}, lexicon["refund"])
+	assert.Equal(t, map[string]float64{"billing": 2}, lexicon["charge"])
+	assert.Equal(t, map[string]float64{DefaultCategory: -3}, lexicon["awful"])
+	assert.Equal(t, map[string]float64{DefaultCategory: -3}, lexicon["can't stand"])
+	assert.Equal(t, 9, len(lexicon))
+
+	_, err = ReadLexicon(strings.NewReader("good\n"))
+	assert.ErrorContains(t, err, "line 1")
+	_, err = ReadLexicon(strings.NewReader("ok fine\ncrash bug high\n"))
+	assert.ErrorContains(t, err, `line 2: invalid weight "high"`)
+
+	_, err = LoadLexicon("does-not-exist.txt")
+	assert.NotNil(t, err)
+}
+
+func TestLexiconScore(t *testing.T) {
+	lexicon, err := ReadLexicon(strings.NewReader(testLexicon))
+	assert.Nil(t, err)
+
+	score := mustScore(t, lexicon, "The app is great, but a crash made the refund slow. Another crash!")
+	assert.Equal(t, 13, score.Words)
+	assert.Equal(t, []CategoryScore{
+		{Category: "bug", Score: 6, Words: []WordScore{{Word: "crash", Count: 2, Score: 6}}},
+		{Category: DefaultCategory, Score: 2, Words: []WordScore{
+			{Word: "great", Count: 1, Score: 3},
+			{Word: "slow", Count: 1, Score: -1},
+		}},
+		{Category: "billing", Score: 1, Words: []WordScore{{Word: "refund", Count: 1, Score: 1}}},
+	}, score.Categories)
+
+	assert.Empty(t, mustScore(t, lexicon, "nothing to see here").Categories)
+	assert.Equal(t, 0, mustScore(t, lexicon, "").Words)
+}
+
+func TestLexiconNegation(t *testing.T) {
+	lexicon, err := ReadLexicon(strings.NewReader(testLexicon))
+	assert.Nil(t, err)
+
+	// A negation inverts the next three words only
+	score := mustScore(t, lexicon, "not very good at all, good")
+	assert.Equal(t, []WordScore{{Word: "good", Count: 2, Negated: 1, Score: 0}}, score.Categories[0].Words)
+	score = mustScore(t, lexicon, "it was not so very much good")
+	assert.Equal(t, 2.0, score.Categories[0].Score)
+
+	// Only weights in the default category are inverted
+	score = mustScore(t, lexicon, "There was no refund.")
+	assert.Equal(t, []CategoryScore{
+		{Category: "billing", Score: 1, Words: []WordScore{{Word: "refund", Count: 1, Score: 1}}},
+	}, score.Categories)
+
+	// A negation ends with its sentence
+	score = mustScore(t, lexicon, "The food was not bad. Good service.")
+	assert.Equal(t, []WordScore{{Word: "good", Count: 1, Score: 2}}, score.Categories[0].Words)
+
+	for _, text := range []string{"It isn't good", "never good", "don't say good"} {
+		assert.Equal(t, -2.0, mustScore(t, lexicon, text).Categories[0].Score, text)
+		split := mustScore(t, lexicon, text, WithTokenizer(WordTokenizer{SplitContractions: true}))
+		assert.Equal(t, -2.0, split.Categories[0].Score, text)
+	}
+
+	// Negations are found even when they are stop words
+	english, err := BuiltinStopWords("en")
+	assert.Nil(t, err)
+	score = mustScore(t, lexicon, "not awful", WithStopWords(english))
+	assert.Equal(t, []WordScore{{Word: "awful", Count: 1, Negated: 1, Score: 3}}, score.Categories[0].Words)
+}
+
+func TestLexiconStemming(t *testing.T) {
+	lexicon, err := ReadLexicon(strings.NewReader(testLexicon))
+	assert.Nil(t, err)
+
+	assert.Empty(t, mustScore(t, lexicon, "two refunds, crashing").Categories)
+	score := mustScore(t, lexicon, "two refunds, crashing", WithStemming())
+	assert.Equal(t, []CategoryScore{
+		{Category: "bug", Score: 3, Words: []WordScore{{Word: "crash", Count: 1, Score: 3}}},
+		{Category: "billing", Score: 1, Words: []WordScore{{Word: "refund", Count: 1, Score: 1}}},
+	}, score.Categories)
+}
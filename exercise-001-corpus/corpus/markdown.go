@@ -0,0 +1,115 @@
+package corpus
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Patterns of the Markdown syntax that markdownText removes
+var (
+	mdFence      = regexp.MustCompile("^ {0,3}(```|~~~)")
+	mdHeading    = regexp.MustCompile(`^ {0,3}#{1,6}(\s+|$)|\s+#+\s*$`)
+	mdRule       = regexp.MustCompile(`^ {0,3}(([-*_=])\s*){3,}$`)
+	mdTableRule  = regexp.MustCompile(`^\s*\|?(\s*:?-+:?\s*\|)+\s*(:?-+:?\s*)?$`)
+	mdRefDef     = regexp.MustCompile(`^ {0,3}\[[^\]]+\]:\s*\S+`)
+	mdQuote      = regexp.MustCompile(`^ {0,3}(>\s?)+`)
+	mdListMarker = regexp.MustCompile(`^\s*([-*+]|\d{1,9}[.)])\s+(\[[ xX]\]\s+)?`)
+	mdImage      = regexp.MustCompile(`!\[([^\]]*)\](\([^)]*\)|\[[^\]]*\])`)
+	mdLink       = regexp.MustCompile(`\[([^\]]*)\](\([^)]*\)|\[[^\]]*\])`)
+	mdAutolink   = regexp.MustCompile(`<[a-zA-Z][a-zA-Z0-9+.-]*:[^>\s]*>`)
+	mdCode       = regexp.MustCompile("`+[^`]*`+")
+	mdHTMLTag    = regexp.MustCompile(`</?[a-zA-Z][^>]*>|<!--.*?-->`)
+	mdEscape     = regexp.MustCompile(`\\([!-/:-@\[-` + "`" + `{-~])`)
+)
+
+// markdownText copies the readable text of a Markdown document from r to w.
+// Code blocks, inline code, link targets and HTML tags are left out, and
+// markers for headings, lists, quotes, tables and emphasis are removed.
+// Line breaks are kept so paragraphs stay separated.
+func markdownText(w io.Writer, r io.Reader) error {
+	br := bufio.NewReader(r)
+	bw := bufio.NewWriter(w)
+	fence := ""       // the fence of the code block being skipped
+	prevBlank := true // an indented code block needs a blank line before it
+	inIndented := false
+
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if line == "" && err == io.EOF {
+			return bw.Flush()
+		}
+		line = strings.TrimRight(line, "\r\n")
+		blank := strings.TrimSpace(line) == ""
+
+		switch {
+		case fence != "":
+			// Skip everything up to the closing fence
+			if strings.HasPrefix(strings.TrimSpace(line), fence) {
+				fence = ""
+			}
+			line = ""
+		case mdFence.MatchString(line):
+			fence = mdFence.FindStringSubmatch(line)[1]
+			line = ""
+		case (prevBlank || inIndented) && !blank && (strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t")):
+			inIndented = true
+			line = ""
+		case mdRule.MatchString(line), mdTableRule.MatchString(line), mdRefDef.MatchString(line):
+			line = ""
+		default:
+			if !blank {
+				inIndented = false
+			}
+			line = markdownInline(line)
+		}
+
+		bw.WriteString(line)
+		bw.WriteByte('\n')
+		prevBlank = blank
+
+		if err == io.EOF {
+			return bw.Flush()
+		}
+	}
+}
+
+// markdownInline removes the Markdown syntax within a single line
+func markdownInline(line string) string {
+	line = mdQuote.ReplaceAllString(line, "")
+	line = mdHeading.ReplaceAllString(line, "")
+	line = mdListMarker.ReplaceAllString(line, "")
+	line = mdCode.ReplaceAllString(line, " ")
+	line = mdImage.ReplaceAllString(line, "$1")
+	line = mdLink.ReplaceAllString(line, "$1")
+	line = mdAutolink.ReplaceAllString(line, " ")
+	line = mdHTMLTag.ReplaceAllString(line, " ")
+	line = strings.ReplaceAll(line, "|", " ")
+
+	// Emphasis markers go, but an underscore inside a word like snake_case stays
+	runes := []rune(line)
+	var text strings.Builder
+	for i, c := range runes {
+		switch {
+		case c == '\\' && i+1 < len(runes) && mdEscape.MatchString(string(runes[i:i+2])):
+			text.WriteRune(runes[i+1])
+			runes[i+1] = 0
+		case c == 0:
+		case c == '*' || c == '~':
+		case c == '_' && !(i > 0 && isWordRune(runes[i-1]) && i+1 < len(runes) && isWordRune(runes[i+1])):
+		default:
+			text.WriteRune(c)
+		}
+	}
+	return text.String()
+}
+
+// isWordRune reports whether c is a letter or digit
+func isWordRune(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c)
+}
@@ -0,0 +1,267 @@
+package corpus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	// DefaultShingleSize is the number of consecutive words in a shingle
+	DefaultShingleSize = 3
+
+	// DefaultSignatureSize is the number of hashes in a MinHash signature.
+	// The error of a similarity estimate is about 1/sqrt(size).
+	DefaultSignatureSize = 128
+)
+
+// Signature is the MinHash signature of a document: for every hash
+// function, the smallest hash of any of its shingles. The share of equal
+// entries in two signatures estimates the Jaccard similarity of the
+// documents' shingle sets.
+type Signature []uint64
+
+// MinHash returns the signature of size hashes over the shingles of r, the
+// runs of shingle consecutive terms. Terms are made as AnalysisReader
+// makes them, but WithNGrams is ignored. A document shorter than a
+// shingle is a single shingle of all its terms; an empty one has no
+// shingles and is only similar to other empty documents.
+func MinHash(r io.Reader, shingle, size int, opts ...Option) (Signature, error) {
+	if shingle < 1 || size < 1 {
+		return nil, fmt.Errorf("shingle and signature size must be at least 1, got %d and %d", shingle, size)
+	}
+
+	signature := make(Signature, size)
+	for i := range signature {
+		signature[i] = math.MaxUint64
+	}
+	add := func(text string) {
+		h := fnv.New64a()
+		io.WriteString(h, text)
+		sum := h.Sum64()
+		for i := range signature {
+			signature[i] = min(signature[i], mix64(sum^minHashSeed(i)))
+		}
+	}
+
+	// Until the first full shingle, the terms are kept for short documents
+	var short []string
+	shingles := 0
+	window, _ := ngramWindow(shingle, func(text string) {
+		add(text)
+		shingles++
+	})
+	cfg := *newConfig(opts)
+	cfg.ngramSize = 1
+	err := scanTerms(r, &cfg, func(term string) {
+		if shingles == 0 {
+			short = append(short, term)
+		}
+		window(term)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if shingles == 0 && len(short) > 0 {
+		add(strings.Join(short, " "))
+	}
+	return signature, nil
+}
+
+// Similarity estimates the Jaccard similarity of the documents of two
+// signatures, between 0 and 1. Signatures of different sizes can't be
+// compared and have similarity 0.
+func (s Signature) Similarity(other Signature) float64 {
+	if len(s) != len(other) || len(s) == 0 {
+		return 0
+	}
+	equal := 0
+	for i := range s {
+		if s[i] == other[i] {
+			equal++
+		}
+	}
+	return float64(equal) / float64(len(s))
+}
+
+// minHashSeed returns the seed of the i-th hash function. The seeds are
+// fixed so signatures made in different runs can be compared.
+func minHashSeed(i int) uint64 {
+	return mix64(uint64(i+1) * 0x9e3779b97f4a7c15)
+}
+
+// mix64 scrambles the bits of x (the SplitMix64 finalizer)
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// LSHIndex finds near-duplicate documents by locality-sensitive hashing:
+// signatures are cut into bands, and documents that share any band are
+// candidates whose estimated similarity is then checked. The bands are
+// chosen so documents above the threshold very likely share one.
+type LSHIndex struct {
+	threshold  float64
+	size       int
+	bands      int
+	rows       int
+	buckets    []map[uint64][]int // per band, the documents by band hash
+	names      []string
+	signatures []Signature
+}
+
+// NearDuplicate is an indexed document similar to a queried one
+type NearDuplicate struct {
+	Name       string  `json:"name"`
+	Similarity float64 `json:"similarity"`
+}
+
+// NewLSHIndex returns an index of signatures of the given size that finds
+// documents with a similarity of at least threshold, between 0 and 1
+func NewLSHIndex(size int, threshold float64) (*LSHIndex, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("signature size must be at least 1, got %d", size)
+	}
+	if threshold <= 0 || threshold > 1 {
+		return nil, fmt.Errorf("similarity threshold must be above 0 and at most 1, got %g", threshold)
+	}
+
+	// Documents at the threshold share a band with probability
+	// 1-(1-t^rows)^bands. The steepest rise of that curve is at about
+	// (1/bands)^(1/rows), which is kept a tenth below the threshold so few
+	// near-duplicates are missed; candidates below it are checked anyway.
+	bands, rows := size, 1
+	for r := 1; r <= size; r++ {
+		b := size / r
+		if math.Pow(1/float64(b), 1/float64(r)) > 0.9*threshold {
+			break
+		}
+		bands, rows = b, r
+	}
+
+	index := &LSHIndex{threshold: threshold, size: size, bands: bands, rows: rows}
+	index.buckets = make([]map[uint64][]int, bands)
+	for i := range index.buckets {
+		index.buckets[i] = make(map[uint64][]int)
+	}
+	return index, nil
+}
+
+// Len returns the number of signatures added to the index
+func (x *LSHIndex) Len() int {
+	return len(x.names)
+}
+
+// Add indexes the signature of a document
+func (x *LSHIndex) Add(name string, signature Signature) error {
+	if len(signature) != x.size {
+		return fmt.Errorf("signature of %s has %d hashes, the index expects %d", name, len(signature), x.size)
+	}
+	id := len(x.names)
+	x.names = append(x.names, name)
+	x.signatures = append(x.signatures, signature)
+	for band, bucket := range x.buckets {
+		key := x.bandKey(signature, band)
+		bucket[key] = append(bucket[key], id)
+	}
+	return nil
+}
+
+// Query returns the indexed documents that are at least as similar to the
+// signature as the threshold, most similar first
+func (x *LSHIndex) Query(signature Signature) []NearDuplicate {
+	if len(signature) != x.size {
+		return nil
+	}
+	var found []NearDuplicate
+	for _, id := range x.candidates(signature) {
+		if similarity := signature.Similarity(x.signatures[id]); similarity >= x.threshold {
+			found = append(found, NearDuplicate{Name: x.names[id], Similarity: similarity})
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].Similarity > found[j].Similarity
+	})
+	return found
+}
+
+// Clusters groups the indexed documents that are near-duplicates of each
+// other, directly or through other documents in the group. Documents
+// without near-duplicates are left out. Clusters and their documents are
+// in the order the documents were added.
+func (x *LSHIndex) Clusters() [][]string {
+	parent := make([]int, len(x.names))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for id, signature := range x.signatures {
+		for _, other := range x.candidates(signature) {
+			if other < id && signature.Similarity(x.signatures[other]) >= x.threshold {
+				// The root is always the earliest document of a cluster
+				a, b := find(other), find(id)
+				parent[max(a, b)] = min(a, b)
+			}
+		}
+	}
+
+	members := make(map[int][]string)
+	var roots []int
+	for id, name := range x.names {
+		root := find(id)
+		if root == id {
+			roots = append(roots, root)
+		}
+		members[root] = append(members[root], name)
+	}
+	var clusters [][]string
+	for _, root := range roots {
+		if len(members[root]) > 1 {
+			clusters = append(clusters, members[root])
+		}
+	}
+	return clusters
+}
+
+// candidates returns the documents that share a band with the signature,
+// in the order they were added
+func (x *LSHIndex) candidates(signature Signature) []int {
+	seen := make(map[int]bool)
+	var ids []int
+	for band, bucket := range x.buckets {
+		for _, id := range bucket[x.bandKey(signature, band)] {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// bandKey hashes the rows of one band of a signature
+func (x *LSHIndex) bandKey(signature Signature, band int) uint64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	for _, value := range signature[band*x.rows : (band+1)*x.rows] {
+		binary.LittleEndian.PutUint64(buf[:], value)
+		h.Write(buf[:])
+	}
+	return h.Sum64()
+}
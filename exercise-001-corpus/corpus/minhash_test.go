@@ -0,0 +1,81 @@
+package corpus
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mustMinHash(t *testing.T, text string, opts ...Option) Signature {
+	t.Helper()
+	signature, err := MinHash(strings.NewReader(text), DefaultShingleSize, DefaultSignatureSize, opts...)
+	assert.Nil(t, err)
+	return signature
+}
+
+func TestMinHash(t *testing.T) {
+	book := mustRead(t, "../7oldsamr.txt")
+	signature := mustMinHash(t, book)
+	assert.Equal(t, DefaultSignatureSize, len(signature))
+
+	// Case and punctuation don't make a document different
+	assert.Equal(t, 1.0, signature.Similarity(mustMinHash(t, strings.ToUpper(book))))
+
+	// Changing a few words keeps most shingles
+	edited := strings.Replace(book, "robbers", "bandits", 3)
+	similarity := signature.Similarity(mustMinHash(t, edited))
+	assert.Less(t, similarity, 1.0)
+	assert.Greater(t, similarity, 0.8)
+
+	// Unrelated text shares nothing
+	other := mustMinHash(t, "A completely different document about the weather in spring and the price of tea.")
+	assert.Less(t, signature.Similarity(other), 0.1)
+
+	// Short and empty documents
+	assert.Equal(t, 1.0, mustMinHash(t, "Hello, world").Similarity(mustMinHash(t, "hello world")))
+	assert.Less(t, mustMinHash(t, "Hello, world").Similarity(mustMinHash(t, "world hello")), 0.1)
+	assert.Equal(t, 1.0, mustMinHash(t, "").Similarity(mustMinHash(t, " ")))
+
+	// Options apply to the terms of the shingles
+	assert.Equal(t, 1.0, mustMinHash(t, "the cats were running", WithStemming()).
+		Similarity(mustMinHash(t, "the cat were run", WithStemming())))
+
+	_, err := MinHash(strings.NewReader(book), 0, 10)
+	assert.NotNil(t, err)
+	assert.Equal(t, 0.0, signature.Similarity(signature[:10]))
+}
+
+func TestLSHIndex(t *testing.T) {
+	book := mustRead(t, "../7oldsamr.txt")
+	half := book[:len(book)/2]
+	docs := []struct{ name, text string }{
+		{"original", book},
+		{"weather", "Rain is expected over the weekend, with sunshine returning on Monday afternoon."},
+		{"edited", strings.Replace(book, "robbers", "bandits", 2)},
+		{"half", half},
+		{"copy", strings.ToUpper(book)},
+		{"weather-copy", "Rain is expected over the weekend, with sunshine returning on Monday afternoon!"},
+	}
+
+	index, err := NewLSHIndex(DefaultSignatureSize, 0.8)
+	assert.Nil(t, err)
+	for _, doc := range docs {
+		assert.Nil(t, index.Add(doc.name, mustMinHash(t, doc.text)))
+	}
+	assert.Equal(t, len(docs), index.Len())
+
+	assert.Equal(t, [][]string{{"original", "edited", "copy"}, {"weather", "weather-copy"}}, index.Clusters())
+
+	found := index.Query(mustMinHash(t, book))
+	assert.Equal(t, 3, len(found))
+	assert.Equal(t, 1.0, found[0].Similarity)
+	assert.Equal(t, "edited", found[2].Name)
+	assert.Empty(t, index.Query(mustMinHash(t, "nothing like it")))
+
+	assert.NotNil(t, index.Add("short", Signature{1, 2}))
+	_, err = NewLSHIndex(DefaultSignatureSize, 0)
+	assert.NotNil(t, err)
+	_, err = NewLSHIndex(0, 0.5)
+	assert.NotNil(t, err)
+}
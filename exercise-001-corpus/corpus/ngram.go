@@ -0,0 +1,28 @@
+package corpus
+
+import "strings"
+
+// ngramWindow wraps fn so it is called with every run of n consecutive
+// words, joined with spaces. Only the last n words are kept in memory.
+// Calling reset starts over, so no run spans a gap between words.
+func ngramWindow(n int, fn func(phrase string)) (add func(word string), reset func()) {
+	if n <= 1 {
+		return fn, func() {}
+	}
+
+	window := make([]string, 0, n)
+	reset = func() {
+		window = window[:0]
+	}
+	return func(word string) {
+		// Slide the window forward by dropping the oldest word
+		if len(window) == n {
+			window = append(window[:0], window[1:]...)
+		}
+		window = append(window, word)
+
+		if len(window) == n {
+			fn(strings.Join(window, " "))
+		}
+	}, reset
+}
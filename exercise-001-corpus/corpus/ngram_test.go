@@ -0,0 +1,39 @@
+package corpus
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBigrams(t *testing.T) {
+	result := Analysis("The old man and the old sea. The old man!", WithNGrams(2))
+	assert.Equal(t, KeyValPair{"the old", 3}, result[0])
+	assert.Equal(t, KeyValPair{"old man", 2}, result[1])
+
+	// N-grams don't run across sentences
+	_, ok := result.Lookup("sea the")
+	assert.False(t, ok)
+	assert.Equal(t, 8, result.Total())
+}
+
+func TestNGramsStopWords(t *testing.T) {
+	english, err := BuiltinStopWords("en")
+	assert.Nil(t, err)
+
+	// Removing "the" and "of" doesn't make "samurai old" or "seven old" bigrams
+	result := Analysis("The old samurai. The old robbers. Seven of the old men", WithNGrams(2), WithStopWords(english))
+	assert.Equal(t, Histogram{{"old men", 1}, {"old robbers", 1}, {"old samurai", 1}}, result)
+}
+
+func TestTrigramsReader(t *testing.T) {
+	result, err := AnalysisReader(strings.NewReader("a b c a b c"), WithNGrams(3))
+	assert.Nil(t, err)
+	assert.Equal(t, Histogram{{"a b c", 2}, {"b c a", 1}, {"c a b", 1}}, result)
+}
+
+func TestNGramsShortText(t *testing.T) {
+	assert.Equal(t, 0, len(Analysis("too short", WithNGrams(3))))
+	assert.Equal(t, Analysis("same as words"), Analysis("same as words", WithNGrams(0)))
+}
@@ -0,0 +1,80 @@
+package corpus
+
+import (
+	"io"
+	"strings"
+)
+
+// Option changes how text is turned into counted terms
+type Option func(*config)
+
+// config holds the settings of a single analysis run
+type config struct {
+	ngramSize int
+	stopWords StopWords
+	stem      bool
+	tokenizer Tokenizer
+}
+
+// newConfig applies opts on top of the default settings
+func newConfig(opts []Option) *config {
+	cfg := &config{ngramSize: 1, tokenizer: WordTokenizer{}}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// scanWords splits r into words with the configured tokenizer
+func (cfg *config) scanWords(r io.Reader, fn func(word string)) error {
+	return cfg.tokenizer.Tokenize(r, func(token Token) {
+		fn(token.Text)
+	})
+}
+
+// scanSentenceWords is scanWords for text split with ScanSentences, and
+// calls start before the words of every sentence
+func (cfg *config) scanSentenceWords(r io.Reader, start func(), fn func(word string)) error {
+	var tokenErr error
+	err := ScanSentences(r, func(sentence string) {
+		start()
+		if err := cfg.scanWords(strings.NewReader(sentence), fn); err != nil && tokenErr == nil {
+			tokenErr = err
+		}
+	})
+	if err == nil {
+		err = tokenErr
+	}
+	return err
+}
+
+// term applies the per-word steps, such as stemming, to a single token
+func (cfg *config) term(word string) string {
+	if cfg.stem {
+		return Stem(word)
+	}
+	return word
+}
+
+// terms splits text into words and applies the per-word steps, so a query
+// can be matched against analyzed text. Stop words are not removed.
+func (cfg *config) terms(text string) []string {
+	var terms []string
+	cfg.scanWords(strings.NewReader(text), func(word string) {
+		terms = append(terms, cfg.term(word))
+	})
+	return terms
+}
+
+// WithNGrams counts phrases of n consecutive words instead of single words.
+// The words of a phrase are joined with a single space. A phrase never
+// spans a sentence end or a stop word removed with WithStopWords. Values below 1
+// are treated as 1.
+func WithNGrams(n int) Option {
+	return func(cfg *config) {
+		if n < 1 {
+			n = 1
+		}
+		cfg.ngramSize = n
+	}
+}
@@ -0,0 +1,250 @@
+package corpus
+
+import (
+	"container/heap"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"sort"
+)
+
+// spaceSavingEntryBytes estimates the memory of one tracked word: the map
+// entry, the heap slot and the word itself for words of average length
+const spaceSavingEntryBytes = 128
+
+// CountMinSketch estimates the counts of words in fixed memory. Estimates
+// are never too low, and with probability 1-delta they are at most
+// epsilon times the total count too high.
+type CountMinSketch struct {
+	width, depth int
+	cells        []uint64 // depth rows of width counters
+	total        uint64
+}
+
+// NewCountMinSketch returns a sketch whose estimates are off by at most
+// epsilon*Total() with probability 1-delta. Both must be between 0 and 1.
+func NewCountMinSketch(epsilon, delta float64) (*CountMinSketch, error) {
+	if epsilon <= 0 || epsilon >= 1 || delta <= 0 || delta >= 1 {
+		return nil, fmt.Errorf("sketch epsilon and delta must be between 0 and 1, got %g and %g", epsilon, delta)
+	}
+	width := int(math.Ceil(math.E / epsilon))
+	depth := int(math.Ceil(math.Log(1 / delta)))
+	return newCountMinSketch(width, max(depth, 1)), nil
+}
+
+func newCountMinSketch(width, depth int) *CountMinSketch {
+	return &CountMinSketch{width: width, depth: depth, cells: make([]uint64, width*depth)}
+}
+
+// Epsilon returns the error bound of the sketch as a fraction of the total
+func (s *CountMinSketch) Epsilon() float64 {
+	return math.E / float64(s.width)
+}
+
+// Delta returns the probability that an estimate exceeds the error bound
+func (s *CountMinSketch) Delta() float64 {
+	return math.Exp(-float64(s.depth))
+}
+
+// Bytes returns the memory used by the counters
+func (s *CountMinSketch) Bytes() int {
+	return len(s.cells) * 8
+}
+
+// Total returns the sum of all counts added
+func (s *CountMinSketch) Total() uint64 {
+	return s.total
+}
+
+// ErrorBound returns the most an estimate is expected to be too high
+func (s *CountMinSketch) ErrorBound() uint64 {
+	return uint64(math.Ceil(s.Epsilon() * float64(s.total)))
+}
+
+// Add counts word count times and returns its new estimate. Only the
+// counters below the new estimate are raised (conservative update), which
+// keeps the error lower than raising all of them.
+func (s *CountMinSketch) Add(word string, count uint64) uint64 {
+	s.total += count
+	estimate := s.Estimate(word) + count
+	s.forEachCell(word, func(cell *uint64) {
+		*cell = max(*cell, estimate)
+	})
+	return estimate
+}
+
+// Estimate returns the estimated count of word
+func (s *CountMinSketch) Estimate(word string) uint64 {
+	estimate := uint64(math.MaxUint64)
+	s.forEachCell(word, func(cell *uint64) {
+		estimate = min(estimate, *cell)
+	})
+	return estimate
+}
+
+// forEachCell calls fn with the counter of word in every row. The row
+// hashes are derived from one 64-bit hash (Kirsch and Mitzenmacher).
+func (s *CountMinSketch) forEachCell(word string, fn func(cell *uint64)) {
+	h := fnv.New64a()
+	io.WriteString(h, word)
+	sum := h.Sum64()
+	h1, h2 := sum&0xffffffff, sum>>32|1
+	for row := 0; row < s.depth; row++ {
+		column := (h1 + uint64(row)*h2) % uint64(s.width)
+		fn(&s.cells[row*s.width+int(column)])
+	}
+}
+
+// HeavyHitter is a frequent word found by HeavyHitters. Its true count is
+// between Count-Error and Count.
+type HeavyHitter struct {
+	Word  string `json:"word"`
+	Count int    `json:"count"`
+	Error int    `json:"error"`
+}
+
+// HeavyHitters finds the most frequent words of an unbounded stream in
+// fixed memory. Space-Saving tracks the k most frequent candidates and a
+// Count-Min sketch tightens their counts. Every word that makes up more
+// than 1/k of the stream is guaranteed to be tracked.
+type HeavyHitters struct {
+	k       int
+	sketch  *CountMinSketch
+	tracked map[string]*trackedWord
+	heap    trackedHeap // the tracked word with the lowest count first
+}
+
+// trackedWord is a Space-Saving counter. The word was seen at most count
+// times and at least count-err times.
+type trackedWord struct {
+	word       string
+	count, err uint64
+	index      int // position in the heap
+}
+
+// NewHeavyHitters tracks the top k words, with a sketch of the given
+// accuracy (see NewCountMinSketch)
+func NewHeavyHitters(k int, epsilon, delta float64) (*HeavyHitters, error) {
+	sketch, err := NewCountMinSketch(epsilon, delta)
+	if err != nil {
+		return nil, err
+	}
+	return newHeavyHitters(k, sketch)
+}
+
+// NewHeavyHittersForMemory tracks the top k words in about budget bytes.
+// What is not needed for the k words goes to the sketch, whose accuracy
+// follows from its size and is reported by Sketch().Epsilon().
+func NewHeavyHittersForMemory(k, budget int, delta float64) (*HeavyHitters, error) {
+	if delta <= 0 || delta >= 1 {
+		return nil, fmt.Errorf("sketch delta must be between 0 and 1, got %g", delta)
+	}
+	depth := max(int(math.Ceil(math.Log(1/delta))), 1)
+	width := (budget - k*spaceSavingEntryBytes) / (8 * depth)
+	if width < 1 {
+		return nil, fmt.Errorf("a memory budget of %d bytes is too small to track %d words", budget, k)
+	}
+	return newHeavyHitters(k, newCountMinSketch(width, depth))
+}
+
+func newHeavyHitters(k int, sketch *CountMinSketch) (*HeavyHitters, error) {
+	if k < 1 {
+		return nil, fmt.Errorf("heavy hitters need k of at least 1, got %d", k)
+	}
+	return &HeavyHitters{k: k, sketch: sketch, tracked: make(map[string]*trackedWord, k)}, nil
+}
+
+// Sketch returns the Count-Min sketch behind h
+func (h *HeavyHitters) Sketch() *CountMinSketch {
+	return h.sketch
+}
+
+// Bytes estimates the memory used by h when all k words are tracked
+func (h *HeavyHitters) Bytes() int {
+	return h.sketch.Bytes() + h.k*spaceSavingEntryBytes
+}
+
+// Total returns the number of words counted
+func (h *HeavyHitters) Total() int {
+	return int(h.sketch.Total())
+}
+
+// Add counts one occurrence of word
+func (h *HeavyHitters) Add(word string) {
+	h.sketch.Add(word, 1)
+
+	if t, ok := h.tracked[word]; ok {
+		t.count++
+		heap.Fix(&h.heap, t.index)
+		return
+	}
+	if len(h.heap) < h.k {
+		t := &trackedWord{word: word, count: 1}
+		h.tracked[word] = t
+		heap.Push(&h.heap, t)
+		return
+	}
+
+	// Replace the least frequent word. The new one may have been seen as
+	// often as the evicted one before, which is its error.
+	t := h.heap[0]
+	delete(h.tracked, t.word)
+	t.word, t.err = word, t.count
+	t.count++
+	h.tracked[word] = t
+	heap.Fix(&h.heap, 0)
+}
+
+// AddReader counts the words read from r, tokenized and filtered like
+// AnalysisReader does. It can be called repeatedly as a stream goes on.
+func (h *HeavyHitters) AddReader(r io.Reader, opts ...Option) error {
+	return scanTerms(r, newConfig(opts), h.Add)
+}
+
+// Top returns the n most frequent words, or all k tracked words if n is
+// negative or larger. Counts are capped by the sketch estimate, which may
+// be lower than the Space-Saving count of a word that replaced another.
+func (h *HeavyHitters) Top(n int) []HeavyHitter {
+	hitters := make([]HeavyHitter, 0, len(h.heap))
+	for _, t := range h.heap {
+		count := min(t.count, h.sketch.Estimate(t.word))
+		lower := t.count - t.err
+		hitters = append(hitters, HeavyHitter{Word: t.word, Count: int(count), Error: int(count - min(lower, count))})
+	}
+	sort.Slice(hitters, func(i, j int) bool {
+		if hitters[i].Count != hitters[j].Count {
+			return hitters[i].Count > hitters[j].Count
+		}
+		return hitters[i].Word < hitters[j].Word
+	})
+	if n >= 0 && n < len(hitters) {
+		hitters = hitters[:n]
+	}
+	return hitters
+}
+
+// trackedHeap is a min-heap of tracked words by count, see container/heap
+type trackedHeap []*trackedWord
+
+func (th trackedHeap) Len() int           { return len(th) }
+func (th trackedHeap) Less(i, j int) bool { return th[i].count < th[j].count }
+
+func (th trackedHeap) Swap(i, j int) {
+	th[i], th[j] = th[j], th[i]
+	th[i].index = i
+	th[j].index = j
+}
+
+func (th *trackedHeap) Push(x any) {
+	t := x.(*trackedWord)
+	t.index = len(*th)
+	*th = append(*th, t)
+}
+
+func (th *trackedHeap) Pop() any {
+	old := *th
+	t := old[len(old)-1]
+	*th = old[:len(old)-1]
+	return t
+}
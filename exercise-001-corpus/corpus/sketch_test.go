@@ -0,0 +1,113 @@
+package corpus
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// skewedStream returns a stream where the words w1 to w5 occur 1000/i
+// times, mixed with 300 rare words that occur twice each
+func skewedStream() ([]string, map[string]int) {
+	counts := make(map[string]int)
+	for i := 1; i <= 5; i++ {
+		counts[fmt.Sprintf("w%d", i)] = 1000 / i
+	}
+	for i := 0; i < 300; i++ {
+		counts[fmt.Sprintf("rare%d", i)] = 2
+	}
+
+	// Take one of every word in turn so the frequent ones don't all come first
+	remaining := maps.Clone(counts)
+	words := slices.Sorted(maps.Keys(counts))
+	var stream []string
+	for len(remaining) > 0 {
+		for _, word := range words {
+			if remaining[word] > 0 {
+				stream = append(stream, word)
+				if remaining[word]--; remaining[word] == 0 {
+					delete(remaining, word)
+				}
+			}
+		}
+	}
+	return stream, counts
+}
+
+func TestCountMinSketch(t *testing.T) {
+	stream, counts := skewedStream()
+	sketch, err := NewCountMinSketch(0.001, 0.01)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, sketch.depth)
+	for _, word := range stream {
+		sketch.Add(word, 1)
+	}
+	assert.Equal(t, uint64(len(stream)), sketch.Total())
+
+	for word, count := range counts {
+		estimate := sketch.Estimate(word)
+		assert.GreaterOrEqual(t, estimate, uint64(count), word)
+		assert.LessOrEqual(t, estimate, uint64(count)+sketch.ErrorBound(), word)
+	}
+	assert.Equal(t, uint64(0), sketch.Estimate("unseen"))
+
+	_, err = NewCountMinSketch(0, 0.01)
+	assert.NotNil(t, err)
+	_, err = NewCountMinSketch(0.01, 1)
+	assert.NotNil(t, err)
+}
+
+func TestHeavyHitters(t *testing.T) {
+	stream, counts := skewedStream()
+	hh, err := NewHeavyHitters(20, 0.001, 0.01)
+	assert.Nil(t, err)
+	for _, word := range stream {
+		hh.Add(word)
+	}
+	assert.Equal(t, len(stream), hh.Total())
+
+	top := hh.Top(5)
+	assert.Equal(t, 5, len(top))
+	for i, hitter := range top {
+		assert.Equal(t, fmt.Sprintf("w%d", i+1), hitter.Word)
+		assert.LessOrEqual(t, hitter.Count-hitter.Error, counts[hitter.Word])
+		assert.GreaterOrEqual(t, hitter.Count, counts[hitter.Word])
+	}
+	assert.Equal(t, 20, len(hh.Top(-1)))
+
+	_, err = NewHeavyHitters(0, 0.001, 0.01)
+	assert.NotNil(t, err)
+}
+
+func TestHeavyHittersForMemory(t *testing.T) {
+	hh, err := NewHeavyHittersForMemory(100, 1<<20, 0.01)
+	assert.Nil(t, err)
+	assert.LessOrEqual(t, hh.Bytes(), 1<<20)
+	assert.Less(t, hh.Sketch().Epsilon(), 0.0002)
+	assert.InDelta(t, 0.0067, hh.Sketch().Delta(), 0.0001)
+
+	_, err = NewHeavyHittersForMemory(100, 1000, 0.01)
+	assert.NotNil(t, err)
+}
+
+func TestHeavyHittersReader(t *testing.T) {
+	file, err := os.Open("../7oldsamr.txt")
+	assert.Nil(t, err)
+	defer file.Close()
+
+	hh, err := NewHeavyHitters(50, 0.001, 0.01)
+	assert.Nil(t, err)
+	assert.Nil(t, hh.AddReader(file))
+
+	exact := Analysis(mustRead(t, "../7oldsamr.txt")).TopN(3)
+	top := hh.Top(3)
+	for i := range exact {
+		assert.Equal(t, exact[i].Word, top[i].Word)
+		assert.LessOrEqual(t, top[i].Count-top[i].Error, exact[i].Count)
+		assert.GreaterOrEqual(t, top[i].Count, exact[i].Count)
+	}
+}
@@ -0,0 +1,204 @@
+package corpus
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// A snapshot file stores a Histogram as:
+//
+//	magic    "CHST"
+//	version  uvarint
+//	entries  uvarint
+//	entry    uvarint word length, word bytes, uvarint count (repeated)
+//	checksum CRC-32 (IEEE) of everything before it, big-endian
+const (
+	snapshotMagic   = "CHST"
+	snapshotVersion = 1
+
+	// maxSnapshotWord bounds the memory a corrupt word length can claim
+	maxSnapshotWord = 1 << 16
+)
+
+// ErrCorruptSnapshot is returned for snapshots that fail their checksum or
+// can't be parsed
+var ErrCorruptSnapshot = errors.New("corrupt histogram snapshot")
+
+// WriteTo writes the histogram to w in the snapshot format. It implements
+// io.WriterTo.
+func (h Histogram) WriteTo(w io.Writer) (int64, error) {
+	checksum := crc32.NewIEEE()
+	cw := &countingWriter{w: io.MultiWriter(w, checksum)}
+	var buf [binary.MaxVarintLen64]byte
+	uvarint := func(v uint64) {
+		cw.Write(buf[:binary.PutUvarint(buf[:], v)])
+	}
+
+	io.WriteString(cw, snapshotMagic)
+	uvarint(snapshotVersion)
+	uvarint(uint64(len(h)))
+	for _, keyVal := range h {
+		uvarint(uint64(len(keyVal.Word)))
+		io.WriteString(cw, keyVal.Word)
+		uvarint(uint64(keyVal.Count))
+	}
+	binary.BigEndian.PutUint32(buf[:4], checksum.Sum32())
+	cw.Write(buf[:4])
+	return cw.n, cw.err
+}
+
+// ReadHistogram reads a histogram written by Histogram.WriteTo. An error
+// wrapping ErrCorruptSnapshot is returned if the data is damaged.
+func ReadHistogram(r io.Reader) (Histogram, error) {
+	br := bufio.NewReader(r)
+	checksum := crc32.NewIEEE()
+	tr := &byteTeeReader{r: br, w: checksum}
+
+	corrupt := func(format string, args ...any) (Histogram, error) {
+		return nil, fmt.Errorf("%w: %s", ErrCorruptSnapshot, fmt.Sprintf(format, args...))
+	}
+	// A snapshot that ends early is corrupt too
+	readErr := func(err error) (Histogram, error) {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return corrupt("truncated")
+		}
+		return nil, err
+	}
+
+	magic := make([]byte, len(snapshotMagic))
+	if _, err := io.ReadFull(tr, magic); err != nil {
+		return readErr(err)
+	}
+	if string(magic) != snapshotMagic {
+		return nil, fmt.Errorf("not a histogram snapshot")
+	}
+	version, err := binary.ReadUvarint(tr)
+	if err != nil {
+		return readErr(err)
+	}
+	if version != snapshotVersion {
+		// Whatever the layout of other versions, they end with the checksum.
+		// A damaged version number must not pass for a newer format.
+		rest, err := io.ReadAll(br)
+		if err != nil {
+			return nil, err
+		}
+		if len(rest) < 4 {
+			return corrupt("truncated")
+		}
+		checksum.Write(rest[:len(rest)-4])
+		if binary.BigEndian.Uint32(rest[len(rest)-4:]) != checksum.Sum32() {
+			return corrupt("checksum mismatch")
+		}
+		return nil, fmt.Errorf("unsupported histogram snapshot version %d", version)
+	}
+
+	entries, err := binary.ReadUvarint(tr)
+	if err != nil {
+		return readErr(err)
+	}
+	histogramMap := make(map[string]int, min(entries, 1<<16))
+	for i := uint64(0); i < entries; i++ {
+		length, err := binary.ReadUvarint(tr)
+		if err != nil {
+			return readErr(err)
+		}
+		if length > maxSnapshotWord {
+			return corrupt("word of %d bytes", length)
+		}
+		word := make([]byte, length)
+		if _, err := io.ReadFull(tr, word); err != nil {
+			return readErr(err)
+		}
+		count, err := binary.ReadUvarint(tr)
+		if err != nil {
+			return readErr(err)
+		}
+		if _, ok := histogramMap[string(word)]; ok {
+			return corrupt("duplicate word %q", word)
+		}
+		histogramMap[string(word)] = int(count)
+	}
+
+	// The checksum itself is not part of the checksum
+	sum := checksum.Sum32()
+	var trailer [4]byte
+	if _, err := io.ReadFull(br, trailer[:]); err != nil {
+		return readErr(err)
+	}
+	if binary.BigEndian.Uint32(trailer[:]) != sum {
+		return corrupt("checksum mismatch")
+	}
+	return newHistogram(histogramMap), nil
+}
+
+// SaveHistogram writes the histogram as a snapshot file at path. The file
+// is replaced atomically, so a failed save leaves the previous version intact.
+func SaveHistogram(path string, h Histogram) error {
+	return writeFileAtomic(path, func(w io.Writer) error {
+		_, err := h.WriteTo(w)
+		return err
+	})
+}
+
+// LoadHistogram reads the snapshot file at path
+func LoadHistogram(path string) (Histogram, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	br := bufio.NewReader(file)
+	histogram, err := ReadHistogram(br)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if _, err := br.ReadByte(); err != io.EOF {
+		return nil, fmt.Errorf("%s: %w: data after checksum", path, ErrCorruptSnapshot)
+	}
+	return histogram, nil
+}
+
+// countingWriter counts the bytes written and keeps the first error
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+	return n, err
+}
+
+// byteTeeReader is an io.TeeReader that is also an io.ByteReader, as
+// binary.ReadUvarint needs
+type byteTeeReader struct {
+	r *bufio.Reader
+	w io.Writer
+}
+
+func (t *byteTeeReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	t.w.Write(p[:n])
+	return n, err
+}
+
+func (t *byteTeeReader) ReadByte() (byte, error) {
+	c, err := t.r.ReadByte()
+	if err == nil {
+		t.w.Write([]byte{c})
+	}
+	return c, err
+}
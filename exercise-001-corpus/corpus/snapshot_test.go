@@ -0,0 +1,88 @@
+package corpus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	histogram := Analysis("the samurai and the robbers, the über samurai")
+
+	var buf bytes.Buffer
+	n, err := histogram.WriteTo(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+
+	loaded, err := ReadHistogram(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, histogram, loaded)
+
+	// An empty histogram survives too
+	buf.Reset()
+	Histogram{}.WriteTo(&buf)
+	loaded, err = ReadHistogram(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(loaded))
+}
+
+func TestSnapshotCorruption(t *testing.T) {
+	var buf bytes.Buffer
+	Analysis("seven old samurai").WriteTo(&buf)
+	valid := buf.Bytes()
+
+	// Flipping any bit is detected
+	for i := len(snapshotMagic); i < len(valid); i++ {
+		damaged := bytes.Clone(valid)
+		damaged[i] ^= 0x10
+		_, err := ReadHistogram(bytes.NewReader(damaged))
+		assert.True(t, errors.Is(err, ErrCorruptSnapshot), "byte %d: %v", i, err)
+	}
+
+	_, err := ReadHistogram(bytes.NewReader(valid[:len(valid)-2]))
+	assert.True(t, errors.Is(err, ErrCorruptSnapshot))
+
+	_, err = ReadHistogram(bytes.NewReader([]byte("seven old samurai")))
+	assert.NotNil(t, err)
+
+	// A newer version with an intact checksum is not corrupt, just unknown
+	wrongVersion := append([]byte(snapshotMagic), 2, 0)
+	wrongVersion = binary.BigEndian.AppendUint32(wrongVersion, crc32.ChecksumIEEE(wrongVersion))
+	_, err = ReadHistogram(bytes.NewReader(wrongVersion))
+	assert.ErrorContains(t, err, "version 2")
+	assert.False(t, errors.Is(err, ErrCorruptSnapshot))
+}
+
+func TestSaveLoadHistogram(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counts.snap")
+	_, err := LoadHistogram(path)
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+
+	// Update the snapshot with a new document, then merge in another machine's
+	first := Analysis("seven old samurai")
+	assert.Nil(t, SaveHistogram(path, first))
+	loaded, err := LoadHistogram(path)
+	assert.Nil(t, err)
+	assert.Nil(t, SaveHistogram(path, loaded.Merge(Analysis("old robbers"))))
+
+	other := filepath.Join(t.TempDir(), "other.snap")
+	assert.Nil(t, SaveHistogram(other, Analysis("the samurai")))
+
+	a, err := LoadHistogram(path)
+	assert.Nil(t, err)
+	b, err := LoadHistogram(other)
+	assert.Nil(t, err)
+	assert.Equal(t, Analysis("seven old samurai old robbers the samurai"), a.Merge(b))
+
+	// Trailing data means the file was not written by SaveHistogram
+	content, _ := os.ReadFile(other)
+	os.WriteFile(other, append(content, 0), 0o644)
+	_, err = LoadHistogram(other)
+	assert.True(t, errors.Is(err, ErrCorruptSnapshot))
+}
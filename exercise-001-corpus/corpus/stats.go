@@ -0,0 +1,284 @@
+package corpus
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// maxSentenceBytes bounds the memory used for one sentence. Longer runs of
+// text without sentence punctuation are split at this size.
+const maxSentenceBytes = 1 << 16
+
+// abbreviations end with a period that does not end a sentence
+var abbreviations = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "dr": true, "prof": true, "sr": true, "jr": true,
+	"st": true, "mt": true, "vs": true, "etc": true, "e.g": true, "i.e": true, "cf": true, "a.m": true, "p.m": true,
+	"no": true, "inc": true, "ltd": true, "co": true, "corp": true, "fig": true, "vol": true,
+	"jan": true, "feb": true, "mar": true, "apr": true, "jun": true, "jul": true, "aug": true,
+	"sep": true, "sept": true, "oct": true, "nov": true, "dec": true,
+}
+
+// Stats is a readability and lexical report of a text
+type Stats struct {
+	Sentences int `json:"sentences"`
+	Words     int `json:"words"`
+	Types     int `json:"types"` // distinct words
+	Syllables int `json:"syllables"`
+
+	AvgSentenceLength   float64 `json:"avgSentenceLength"` // words per sentence
+	AvgSyllablesPerWord float64 `json:"avgSyllablesPerWord"`
+	FleschReadingEase   float64 `json:"fleschReadingEase"`
+	FleschKincaidGrade  float64 `json:"fleschKincaidGrade"`
+
+	TypeTokenRatio   float64 `json:"typeTokenRatio"`
+	HapaxLegomena    int     `json:"hapaxLegomena"`    // words that occur exactly once
+	HapaxDislegomena int     `json:"hapaxDislegomena"` // words that occur exactly twice
+}
+
+// Statistics computes the Stats report of text
+func Statistics(text string, opts ...Option) Stats {
+	stats, _ := StatisticsReader(strings.NewReader(text), opts...)
+	return stats
+}
+
+// StatisticsReader computes the Stats report of the text read from r. Words
+// are found with the configured tokenizer, but stop words and stemming are
+// ignored so the report describes the text as written.
+func StatisticsReader(r io.Reader, opts ...Option) (Stats, error) {
+	c := newStatsCounter(newConfig(opts))
+	if err := c.count(r); err != nil {
+		return Stats{}, err
+	}
+	return c.Stats(), nil
+}
+
+// StatsCounter adds up the counts of several texts into one Stats report,
+// as StatisticsReader makes it. Only the word counts are kept, so texts of
+// any size can be added one at a time.
+type StatsCounter struct {
+	cfg       *config
+	sentences int
+	words     int
+	syllables int
+	counts    map[string]int
+}
+
+// NewStatsCounter returns an empty counter
+func NewStatsCounter(opts ...Option) *StatsCounter {
+	return newStatsCounter(newConfig(opts))
+}
+
+func newStatsCounter(cfg *config) *StatsCounter {
+	return &StatsCounter{cfg: cfg, counts: make(map[string]int)}
+}
+
+// AddReader reads a text and returns its own report. The text is only
+// added to the counter if it was read completely, so a text that fails
+// part-way counts nowhere. Sentences never run across texts.
+func (c *StatsCounter) AddReader(r io.Reader) (Stats, error) {
+	text := newStatsCounter(c.cfg)
+	if err := text.count(r); err != nil {
+		return Stats{}, err
+	}
+	c.sentences += text.sentences
+	c.words += text.words
+	c.syllables += text.syllables
+	for word, count := range text.counts {
+		c.counts[word] += count
+	}
+	return text.Stats(), nil
+}
+
+// count adds the sentences and words of r
+func (c *StatsCounter) count(r io.Reader) error {
+	var tokenErr error
+	err := ScanSentences(r, func(sentence string) {
+		words := 0
+		err := c.cfg.scanWords(strings.NewReader(sentence), func(word string) {
+			c.counts[word]++
+			c.syllables += Syllables(word)
+			words++
+		})
+		if err != nil && tokenErr == nil {
+			tokenErr = err
+		}
+
+		// Sentences without words, like a row of asterisks, don't count
+		if words > 0 {
+			c.sentences++
+			c.words += words
+		}
+	})
+	if err == nil {
+		err = tokenErr
+	}
+	return err
+}
+
+// Stats returns the report of all texts added
+func (c *StatsCounter) Stats() Stats {
+	stats := Stats{
+		Sentences: c.sentences,
+		Words:     c.words,
+		Types:     len(c.counts),
+		Syllables: c.syllables,
+	}
+	for _, count := range c.counts {
+		switch count {
+		case 1:
+			stats.HapaxLegomena++
+		case 2:
+			stats.HapaxDislegomena++
+		}
+	}
+
+	if stats.Words > 0 {
+		stats.AvgSentenceLength = float64(stats.Words) / float64(stats.Sentences)
+		stats.AvgSyllablesPerWord = float64(stats.Syllables) / float64(stats.Words)
+		stats.FleschReadingEase = 206.835 - 1.015*stats.AvgSentenceLength - 84.6*stats.AvgSyllablesPerWord
+		stats.FleschKincaidGrade = 0.39*stats.AvgSentenceLength + 11.8*stats.AvgSyllablesPerWord - 15.59
+		stats.TypeTokenRatio = float64(stats.Types) / float64(stats.Words)
+	}
+	return stats
+}
+
+// SplitSentences splits text into sentences, see ScanSentences
+func SplitSentences(text string) []string {
+	var sentences []string
+	ScanSentences(strings.NewReader(text), func(sentence string) {
+		sentences = append(sentences, sentence)
+	})
+	return sentences
+}
+
+// ScanSentences reads r and calls emit with every sentence, trimmed of
+// surrounding space. A sentence ends with '.', '!', '?' or '…' followed by
+// optional closing quotes and a space, or with a blank line. Periods after
+// common abbreviations ("Dr.", "e.g.") and initials ("J. R. R.") are skipped.
+func ScanSentences(r io.Reader, emit func(sentence string)) error {
+	br := bufio.NewReader(r)
+	var sentence strings.Builder
+	ended := false // the sentence ends at the next space
+	newlines := 0  // newlines since the last non-space character
+
+	flush := func() {
+		if text := strings.TrimSpace(sentence.String()); text != "" {
+			emit(text)
+		}
+		sentence.Reset()
+		ended = false
+	}
+
+	for {
+		c, _, err := br.ReadRune()
+		if err != nil {
+			flush()
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		if unicode.IsSpace(c) {
+			if c == '\n' {
+				newlines++
+			}
+			if ended || newlines >= 2 {
+				flush()
+			} else {
+				sentence.WriteRune(c)
+			}
+			continue
+		}
+		newlines = 0
+
+		sentence.WriteRune(c)
+		switch c {
+		case '.':
+			ended = !endsWithAbbreviation(sentence.String())
+		case '!', '?', '…':
+			ended = true
+		case '。', '！', '？':
+			// CJK punctuation is not followed by a space
+			flush()
+		case '"', '\'', '”', '’', '»', ')', ']':
+			// Closing quotes and brackets belong to the sentence they end
+		default:
+			ended = false
+		}
+
+		if sentence.Len() >= maxSentenceBytes {
+			flush()
+		}
+	}
+}
+
+// endsWithAbbreviation reports whether the period at the end of text
+// belongs to an abbreviation or an initial rather than ending a sentence
+func endsWithAbbreviation(text string) bool {
+	text = strings.TrimSuffix(text, ".")
+	start := strings.LastIndexFunc(text, func(c rune) bool {
+		return !unicode.IsLetter(c) && c != '.'
+	})
+	word := text[start+1:]
+
+	// A single capital letter is an initial
+	if len(word) == 1 && unicode.IsUpper(rune(word[0])) {
+		return true
+	}
+	return abbreviations[strings.ToLower(word)]
+}
+
+// Syllables estimates the number of syllables in an English word by
+// counting groups of vowels. A silent final "e" is not counted, and every
+// word with a letter has at least one syllable.
+func Syllables(word string) int {
+	word = strings.ToLower(word)
+	count := 0
+	inVowels := false
+	letters := 0
+	for _, c := range word {
+		if !unicode.IsLetter(c) {
+			inVowels = false
+			continue
+		}
+		letters++
+		vowel := strings.ContainsRune("aeiouyàáâäèéêëìíîïòóôöùúûü", c)
+		if vowel && !inVowels {
+			count++
+		}
+		inVowels = vowel
+	}
+	if letters == 0 {
+		return 0
+	}
+
+	// A final "e" is usually silent, except in "-le" after a consonant ("table")
+	if count > 1 && strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "ee") {
+		consonantLe := strings.HasSuffix(word, "le") && len(word) > 2 && !strings.ContainsRune("aeiouy", rune(word[len(word)-3]))
+		if !consonantLe {
+			count--
+		}
+	}
+
+	// "-ed" and "-es" usually don't add a syllable, except in "wanted" or "boxes"
+	if count > 1 && strings.HasSuffix(word, "ed") && !hasAnySuffix(word, "ted", "ded") {
+		count--
+	}
+	if count > 1 && strings.HasSuffix(word, "es") && !hasAnySuffix(word, "ces", "ges", "ses", "xes", "zes", "ches", "shes") {
+		count--
+	}
+	return max(count, 1)
+}
+
+// hasAnySuffix reports whether s ends with one of the suffixes
+func hasAnySuffix(s string, suffixes ...string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
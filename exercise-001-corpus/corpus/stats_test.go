@@ -0,0 +1,98 @@
+package corpus
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitSentences(t *testing.T) {
+	text := `THE TITLE
+
+Dr. Smith met J. R. R. Tolkien at 3.14 p.m. today! "Did he?" she asked… Yes, e.g. on Oct. 5. The end`
+	assert.Equal(t, []string{
+		"THE TITLE",
+		"Dr. Smith met J. R. R. Tolkien at 3.14 p.m. today!",
+		`"Did he?"`,
+		"she asked…",
+		"Yes, e.g. on Oct. 5.",
+		"The end",
+	}, SplitSentences(text))
+
+	assert.Equal(t, []string{"东京很大。", "是的！"}, SplitSentences("东京很大。是的！"))
+	assert.Equal(t, 0, len(SplitSentences("  \n ")))
+}
+
+func TestSyllables(t *testing.T) {
+	cases := map[string]int{
+		"the": 1, "cat": 1, "make": 1, "makes": 1, "table": 2, "free": 1, "loved": 1,
+		"wanted": 2, "boxes": 2, "places": 2, "samurai": 3, "beautiful": 3,
+		"readability": 5, "rhythm": 1, "42": 0,
+	}
+	for word, syllables := range cases {
+		assert.Equal(t, syllables, Syllables(word), word)
+	}
+}
+
+func TestStatistics(t *testing.T) {
+	stats := Statistics("The cat sat on the mat. The dog ran!")
+	assert.Equal(t, 2, stats.Sentences)
+	assert.Equal(t, 9, stats.Words)
+	assert.Equal(t, 7, stats.Types)
+	assert.Equal(t, 9, stats.Syllables)
+	assert.Equal(t, 6, stats.HapaxLegomena)
+	assert.Equal(t, 0, stats.HapaxDislegomena)
+	assert.InDelta(t, 4.5, stats.AvgSentenceLength, 1e-9)
+	assert.InDelta(t, 1.0, stats.AvgSyllablesPerWord, 1e-9)
+	assert.InDelta(t, 206.835-1.015*4.5-84.6, stats.FleschReadingEase, 1e-9)
+	assert.InDelta(t, 0.39*4.5+11.8-15.59, stats.FleschKincaidGrade, 1e-9)
+	assert.InDelta(t, 7.0/9, stats.TypeTokenRatio, 1e-9)
+
+	assert.Equal(t, Stats{}, Statistics(""))
+}
+
+func TestStatisticsReader(t *testing.T) {
+	file, err := os.Open("../7oldsamr.txt")
+	assert.Nil(t, err)
+	defer file.Close()
+
+	stats, err := StatisticsReader(file)
+	assert.Nil(t, err)
+	assert.Equal(t, Analysis(mustRead(t, "../7oldsamr.txt")).Total(), stats.Words)
+	assert.True(t, stats.Sentences > 20)
+	assert.True(t, stats.FleschReadingEase > 60)
+
+	_, err = StatisticsReader(iotest.TimeoutReader(strings.NewReader("One. Two.")))
+	assert.Equal(t, iotest.ErrTimeout, err)
+}
+
+func TestStatsCounter(t *testing.T) {
+	counter := NewStatsCounter()
+	first, err := counter.AddReader(strings.NewReader("The cat sat. The dog"))
+	assert.Nil(t, err)
+	assert.Equal(t, Statistics("The cat sat. The dog"), first)
+
+	// A text that fails part-way is left out of the total
+	_, err = counter.AddReader(io.MultiReader(strings.NewReader("Lost words here."), iotest.ErrReader(io.ErrUnexpectedEOF)))
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+
+	_, err = counter.AddReader(strings.NewReader("ran away."))
+	assert.Nil(t, err)
+
+	// Texts don't run into each other, so "The dog" and "ran away" are two sentences
+	total := counter.Stats()
+	assert.Equal(t, 3, total.Sentences)
+	assert.Equal(t, 7, total.Words)
+	assert.Equal(t, 6, total.Types)
+	assert.Equal(t, 5, total.HapaxLegomena)
+}
+
+func mustRead(t *testing.T, path string) string {
+	content, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	return string(content)
+}
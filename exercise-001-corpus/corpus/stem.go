@@ -0,0 +1,298 @@
+package corpus
+
+import (
+	"io"
+	"sort"
+	"strings"
+)
+
+// StemGroup is a stem together with the surface forms that were reduced to it
+type StemGroup struct {
+	Stem  string
+	Count int
+	Forms Histogram
+}
+
+// WithStemming reduces every word to its Porter stem before it is counted,
+// so inflected forms like "run", "runs" and "running" are counted together
+func WithStemming() Option {
+	return func(cfg *config) {
+		cfg.stem = true
+	}
+}
+
+// StemGroups counts the stems of the words in r and lists the surface forms
+// that went into each stem. Groups are ordered like a Histogram. Only single
+// words are grouped, so WithNGrams is ignored.
+func StemGroups(r io.Reader, opts ...Option) ([]StemGroup, error) {
+	cfg := newConfig(opts)
+	formsByStem := make(map[string]map[string]int)
+
+	err := cfg.scanWords(r, stopWordFilter(cfg.stopWords, func(word string) {
+		stem := Stem(word)
+		if formsByStem[stem] == nil {
+			formsByStem[stem] = make(map[string]int)
+		}
+		formsByStem[stem][word]++
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]StemGroup, 0, len(formsByStem))
+	for stem, forms := range formsByStem {
+		histogram := newHistogram(forms)
+		groups = append(groups, StemGroup{Stem: stem, Count: histogram.Total(), Forms: histogram})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].Stem < groups[j].Stem
+	})
+	return groups, nil
+}
+
+// Stem returns the Porter stem of an English word. Words of two letters or
+// less, and words with characters outside a-z, are returned unchanged.
+func Stem(word string) string {
+	if len(word) <= 2 || strings.IndexFunc(word, func(c rune) bool { return c < 'a' || c > 'z' }) >= 0 {
+		return word
+	}
+
+	p := &porter{b: []byte(word), k: len(word) - 1}
+	p.step1ab()
+	if p.k > 0 {
+		p.step1c()
+		p.step2()
+		p.step3()
+		p.step4()
+		p.step5()
+	}
+	return string(p.b[:p.k+1])
+}
+
+// porter holds the state of the Porter stemming algorithm. b[0..k] is the
+// word being stemmed and j marks the end of the stem before a suffix.
+type porter struct {
+	b    []byte
+	j, k int
+}
+
+// cons reports whether b[i] is a consonant
+func (p *porter) cons(i int) bool {
+	switch p.b[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !p.cons(i-1)
+	}
+	return true
+}
+
+// m measures the number of consonant sequences in b[0..j]. With c a
+// consonant sequence and v a vowel sequence, <c>(vc)^m<v> gives m.
+func (p *porter) m() int {
+	n, i := 0, 0
+	for {
+		if i > p.j {
+			return n
+		}
+		if !p.cons(i) {
+			break
+		}
+		i++
+	}
+	i++
+	for {
+		for {
+			if i > p.j {
+				return n
+			}
+			if p.cons(i) {
+				break
+			}
+			i++
+		}
+		i++
+		n++
+		for {
+			if i > p.j {
+				return n
+			}
+			if !p.cons(i) {
+				break
+			}
+			i++
+		}
+		i++
+	}
+}
+
+// vowelInStem reports whether b[0..j] contains a vowel
+func (p *porter) vowelInStem() bool {
+	for i := 0; i <= p.j; i++ {
+		if !p.cons(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// doubleC reports whether b[i-1..i] is a double consonant
+func (p *porter) doubleC(i int) bool {
+	return i >= 1 && p.b[i] == p.b[i-1] && p.cons(i)
+}
+
+// cvc reports whether b[i-2..i] is consonant-vowel-consonant and the last
+// consonant is not w, x or y. It is used to restore an e, as in hop(e).
+func (p *porter) cvc(i int) bool {
+	if i < 2 || !p.cons(i) || p.cons(i-1) || !p.cons(i-2) {
+		return false
+	}
+	switch p.b[i] {
+	case 'w', 'x', 'y':
+		return false
+	}
+	return true
+}
+
+// ends reports whether b[0..k] ends with s, and if so sets j before it
+func (p *porter) ends(s string) bool {
+	if len(s) > p.k+1 || string(p.b[p.k+1-len(s):p.k+1]) != s {
+		return false
+	}
+	p.j = p.k - len(s)
+	return true
+}
+
+// setTo replaces b[j+1..k] with s
+func (p *porter) setTo(s string) {
+	p.b = append(p.b[:p.j+1], s...)
+	p.k = p.j + len(s)
+}
+
+// r replaces the suffix with s if the stem has a measure above zero
+func (p *porter) r(s string) {
+	if p.m() > 0 {
+		p.setTo(s)
+	}
+}
+
+// step1ab removes plurals and -ed or -ing
+func (p *porter) step1ab() {
+	if p.b[p.k] == 's' {
+		switch {
+		case p.ends("sses"):
+			p.k -= 2
+		case p.ends("ies"):
+			p.setTo("i")
+		case p.b[p.k-1] != 's':
+			p.k--
+		}
+	}
+
+	if p.ends("eed") {
+		if p.m() > 0 {
+			p.k--
+		}
+	} else if (p.ends("ed") || p.ends("ing")) && p.vowelInStem() {
+		p.k = p.j
+		switch {
+		case p.ends("at"):
+			p.setTo("ate")
+		case p.ends("bl"):
+			p.setTo("ble")
+		case p.ends("iz"):
+			p.setTo("ize")
+		case p.doubleC(p.k):
+			switch p.b[p.k] {
+			case 'l', 's', 'z':
+			default:
+				p.k--
+			}
+		default:
+			p.j = p.k
+			if p.m() == 1 && p.cvc(p.k) {
+				p.setTo("e")
+			}
+		}
+	}
+}
+
+// step1c turns a terminal y into i when there is another vowel in the stem
+func (p *porter) step1c() {
+	if p.ends("y") && p.vowelInStem() {
+		p.b[p.k] = 'i'
+	}
+}
+
+// replaceSuffix applies the first rule whose suffix matches. Only the first
+// match counts, even if the measure condition then prevents the replacement.
+func (p *porter) replaceSuffix(rules [][2]string) {
+	for _, rule := range rules {
+		if p.ends(rule[0]) {
+			p.r(rule[1])
+			return
+		}
+	}
+}
+
+// step2 maps double suffixes to single ones, e.g. -ization to -ize
+func (p *porter) step2() {
+	p.replaceSuffix([][2]string{
+		{"ational", "ate"}, {"tional", "tion"},
+		{"enci", "ence"}, {"anci", "ance"},
+		{"izer", "ize"},
+		{"bli", "ble"}, {"alli", "al"}, {"entli", "ent"}, {"eli", "e"}, {"ousli", "ous"},
+		{"ization", "ize"}, {"ation", "ate"}, {"ator", "ate"},
+		{"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"}, {"ousness", "ous"},
+		{"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+		{"logi", "log"},
+	})
+}
+
+// step3 handles -ic-, -full, -ness and similar suffixes
+func (p *porter) step3() {
+	p.replaceSuffix([][2]string{
+		{"icate", "ic"}, {"ative", ""}, {"alize", "al"},
+		{"iciti", "ic"},
+		{"ical", "ic"}, {"ful", ""},
+		{"ness", ""},
+	})
+}
+
+// step4 removes -ant, -ence and similar suffixes when the measure is above one
+func (p *porter) step4() {
+	suffixes := []string{
+		"al", "ance", "ence", "er", "ic", "able", "ible", "ant", "ement",
+		"ment", "ent", "ion", "ou", "ism", "ate", "iti", "ous", "ive", "ize",
+	}
+	for _, suffix := range suffixes {
+		if !p.ends(suffix) {
+			continue
+		}
+		// -ion is only removed after s or t
+		if suffix == "ion" && (p.j < 0 || (p.b[p.j] != 's' && p.b[p.j] != 't')) {
+			continue
+		}
+		if p.m() > 1 {
+			p.k = p.j
+		}
+		return
+	}
+}
+
+// step5 removes a final -e and reduces -ll to -l when the measure is above one
+func (p *porter) step5() {
+	p.j = p.k
+	if p.b[p.k] == 'e' {
+		a := p.m()
+		if a > 1 || (a == 1 && !p.cvc(p.k-1)) {
+			p.k--
+		}
+	}
+	if p.b[p.k] == 'l' && p.doubleC(p.k) && p.m() > 1 {
+		p.k--
+	}
+}
@@ -0,0 +1,40 @@
+package corpus
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStem(t *testing.T) {
+	cases := map[string]string{
+		"caresses": "caress", "ponies": "poni", "ties": "ti", "caress": "caress", "cats": "cat",
+		"feed": "feed", "agreed": "agre", "plastered": "plaster", "bled": "bled",
+		"motoring": "motor", "sing": "sing", "conflated": "conflat", "troubled": "troubl",
+		"sized": "size", "hopping": "hop", "tanned": "tan", "falling": "fall",
+		"hissing": "hiss", "fizzed": "fizz", "failing": "fail", "filing": "file",
+		"happy": "happi", "sky": "sky", "relational": "relat", "conditional": "condit",
+		"rational": "ration", "generalization": "gener", "hopeful": "hope",
+		"goodness": "good", "adjustment": "adjust", "adoption": "adopt",
+		"controll": "control", "roll": "roll", "run": "run", "runs": "run", "running": "run",
+		"is": "is", "über": "über",
+	}
+	for word, stem := range cases {
+		assert.Equal(t, stem, Stem(word), word)
+	}
+}
+
+func TestAnalysisWithStemming(t *testing.T) {
+	result := Analysis("Run, runs, running! The runner ran.", WithStemming())
+	assert.Equal(t, Histogram{{"run", 3}, {"ran", 1}, {"runner", 1}, {"the", 1}}, result)
+}
+
+func TestStemGroups(t *testing.T) {
+	english, _ := BuiltinStopWords("en")
+	groups, err := StemGroups(strings.NewReader("Running runs. The run was running."), WithStopWords(english))
+	assert.Nil(t, err)
+	assert.Equal(t, []StemGroup{
+		{Stem: "run", Count: 4, Forms: Histogram{{"running", 2}, {"run", 1}, {"runs", 1}}},
+	}, groups)
+}
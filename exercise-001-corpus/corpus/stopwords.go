@@ -0,0 +1,114 @@
+package corpus
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+//go:embed stopwords/*.txt
+var stopWordFiles embed.FS
+
+// stopWordLanguages maps the accepted language names to their built-in list
+var stopWordLanguages = map[string]string{
+	"en": "en", "english": "en",
+	"es": "es", "spanish": "es",
+	"de": "de", "german": "de",
+	"fr": "fr", "french": "fr",
+}
+
+// StopWords is a set of words that are left out of the analysis
+type StopWords map[string]struct{}
+
+// Contains reports whether word is a stop word
+func (s StopWords) Contains(word string) bool {
+	_, ok := s[word]
+	return ok
+}
+
+// StopWordLanguages returns the codes of the built-in stop-word lists
+func StopWordLanguages() []string {
+	var languages []string
+	for name, code := range stopWordLanguages {
+		if name == code {
+			languages = append(languages, code)
+		}
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// BuiltinStopWords returns the built-in list for language, given either as
+// a two-letter code ("en") or an English name ("english")
+func BuiltinStopWords(language string) (StopWords, error) {
+	code, ok := stopWordLanguages[strings.ToLower(language)]
+	if !ok {
+		return nil, fmt.Errorf("no built-in stop words for language %q", language)
+	}
+
+	file, err := stopWordFiles.Open("stopwords/" + code + ".txt")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadStopWords(file)
+}
+
+// LoadStopWords reads a stop-word list from the file at path
+func LoadStopWords(path string) (StopWords, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadStopWords(file)
+}
+
+// ReadStopWords reads a stop-word list from r. Words are separated by
+// whitespace, and everything after a '#' on a line is a comment.
+func ReadStopWords(r io.Reader) (StopWords, error) {
+	stopWords := make(StopWords)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		for _, word := range strings.Fields(line) {
+			stopWords[strings.ToLower(word)] = struct{}{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return stopWords, nil
+}
+
+// WithStopWords leaves the words in stopWords out of the analysis. It can
+// be given more than once, in which case the sets are combined.
+func WithStopWords(stopWords StopWords) Option {
+	return func(cfg *config) {
+		if cfg.stopWords == nil {
+			cfg.stopWords = make(StopWords)
+		}
+		for word := range stopWords {
+			cfg.stopWords[word] = struct{}{}
+		}
+	}
+}
+
+// stopWordFilter wraps fn so it is only called with words not in stopWords
+func stopWordFilter(stopWords StopWords, fn func(word string)) func(word string) {
+	if len(stopWords) == 0 {
+		return fn
+	}
+	return func(word string) {
+		if !stopWords.Contains(word) {
+			fn(word)
+		}
+	}
+}
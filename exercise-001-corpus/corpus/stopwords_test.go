@@ -0,0 +1,62 @@
+package corpus
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuiltinStopWords(t *testing.T) {
+	assert.Equal(t, []string{"de", "en", "es", "fr"}, StopWordLanguages())
+
+	for _, language := range StopWordLanguages() {
+		stopWords, err := BuiltinStopWords(language)
+		assert.Nil(t, err)
+		assert.True(t, len(stopWords) > 50, language)
+	}
+
+	english, err := BuiltinStopWords("English")
+	assert.Nil(t, err)
+	assert.True(t, english.Contains("the"))
+	assert.False(t, english.Contains("samurai"))
+
+	french, err := BuiltinStopWords("fr")
+	assert.Nil(t, err)
+	assert.True(t, french.Contains("où"))
+
+	_, err = BuiltinStopWords("klingon")
+	assert.NotNil(t, err)
+}
+
+func TestReadStopWords(t *testing.T) {
+	stopWords, err := ReadStopWords(strings.NewReader("# comment\nFoo bar\n\nbaz # trailing comment"))
+	assert.Nil(t, err)
+	assert.Equal(t, StopWords{"foo": {}, "bar": {}, "baz": {}}, stopWords)
+}
+
+func TestLoadStopWords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stop.txt")
+	assert.Nil(t, os.WriteFile(path, []byte("samurai\nrobbers\n"), 0644))
+
+	stopWords, err := LoadStopWords(path)
+	assert.Nil(t, err)
+	assert.True(t, stopWords.Contains("robbers"))
+
+	_, err = LoadStopWords(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.NotNil(t, err)
+}
+
+func TestAnalysisWithStopWords(t *testing.T) {
+	english, _ := BuiltinStopWords("en")
+	custom := StopWords{"sea": {}}
+
+	result := Analysis("The old man and the sea", WithStopWords(english), WithStopWords(custom))
+	assert.Equal(t, Histogram{{"man", 1}, {"old", 1}}, result)
+
+	// A removed stop word breaks a phrase, so "man sea" is not one
+	result = Analysis("The old man and the sea", WithStopWords(english), WithNGrams(2))
+	assert.Equal(t, Histogram{{"old man", 1}}, result)
+}
@@ -0,0 +1,199 @@
+package corpus
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+// TFWeighting selects how the term frequency of a word in a document is weighted
+type TFWeighting int
+
+const (
+	RawTF       TFWeighting = iota // the number of occurrences
+	BooleanTF                      // 1 if the word occurs at all
+	LogTF                          // 1 + log(count)
+	AugmentedTF                    // 0.5 + 0.5 * count / max count in the document
+	RelativeTF                     // count / number of words in the document
+)
+
+// IDFWeighting selects how the inverse document frequency of a word is weighted
+type IDFWeighting int
+
+const (
+	StandardIDF      IDFWeighting = iota // log(N / df)
+	SmoothIDF                            // log((1 + N) / (1 + df)) + 1
+	ProbabilisticIDF                     // max(0, log((N - df) / df))
+	NoIDF                                // 1, which ranks by term frequency only
+)
+
+// Weighting is the TF-IDF variant used by Collection.TFIDF. The zero
+// value is raw counts times the standard IDF, without normalization.
+type Weighting struct {
+	TF        TFWeighting
+	IDF       IDFWeighting
+	Normalize bool // scale the scores of a document to unit length (L2)
+}
+
+// ScoredWord is a word with a score, such as its TF-IDF weight
+type ScoredWord struct {
+	Word  string  `json:"word"`
+	Score float64 `json:"score"`
+}
+
+// document is a single member of a Collection
+type document struct {
+	name   string
+	counts map[string]int
+	total  int
+}
+
+// Collection is a set of documents used to find the words that characterize
+// one document compared with the rest
+type Collection struct {
+	opts    []Option
+	docs    []*document
+	byName  map[string]*document
+	docFreq map[string]int
+}
+
+// NewCollection creates an empty collection. Every document is tokenized
+// with opts, so the same tokenizer, stop words and stemming apply to all.
+func NewCollection(opts ...Option) *Collection {
+	return &Collection{
+		opts:    opts,
+		byName:  make(map[string]*document),
+		docFreq: make(map[string]int),
+	}
+}
+
+// Add reads a document from r and adds it to the collection under name
+func (c *Collection) Add(name string, r io.Reader) error {
+	if _, ok := c.byName[name]; ok {
+		return fmt.Errorf("document %q is already in the collection", name)
+	}
+
+	doc := &document{name: name, counts: make(map[string]int)}
+	err := scanTerms(r, newConfig(c.opts), func(term string) {
+		doc.counts[term]++
+		doc.total++
+	})
+	if err != nil {
+		return err
+	}
+
+	c.docs = append(c.docs, doc)
+	c.byName[name] = doc
+	for word := range doc.counts {
+		c.docFreq[word]++
+	}
+	return nil
+}
+
+// AddText adds the document text to the collection under name
+func (c *Collection) AddText(name, text string) error {
+	return c.Add(name, strings.NewReader(text))
+}
+
+// Len returns the number of documents in the collection
+func (c *Collection) Len() int {
+	return len(c.docs)
+}
+
+// Names returns the document names in the order they were added
+func (c *Collection) Names() []string {
+	names := make([]string, len(c.docs))
+	for i, doc := range c.docs {
+		names[i] = doc.name
+	}
+	return names
+}
+
+// DocumentFrequency returns the number of documents containing word
+func (c *Collection) DocumentFrequency(word string) int {
+	return c.docFreq[word]
+}
+
+// Histogram returns the word counts of the named document
+func (c *Collection) Histogram(name string) (Histogram, error) {
+	doc, ok := c.byName[name]
+	if !ok {
+		return nil, fmt.Errorf("document %q is not in the collection", name)
+	}
+	return newHistogram(doc.counts), nil
+}
+
+// TFIDF ranks the words of the named document by their TF-IDF score,
+// highest first. Ties are ordered alphabetically.
+func (c *Collection) TFIDF(name string, weighting Weighting) ([]ScoredWord, error) {
+	doc, ok := c.byName[name]
+	if !ok {
+		return nil, fmt.Errorf("document %q is not in the collection", name)
+	}
+
+	maxCount := 0
+	for _, count := range doc.counts {
+		maxCount = max(maxCount, count)
+	}
+
+	scores := make([]ScoredWord, 0, len(doc.counts))
+	norm := 0.0
+	for word, count := range doc.counts {
+		score := weighting.tf(count, maxCount, doc.total) * weighting.idf(len(c.docs), c.docFreq[word])
+		scores = append(scores, ScoredWord{Word: word, Score: score})
+		norm += score * score
+	}
+
+	if weighting.Normalize && norm > 0 {
+		norm = math.Sqrt(norm)
+		for i := range scores {
+			scores[i].Score /= norm
+		}
+	}
+
+	sortScores(scores)
+	return scores, nil
+}
+
+// tf weighs the count of a word in a document
+func (w Weighting) tf(count, maxCount, total int) float64 {
+	switch w.TF {
+	case BooleanTF:
+		return 1
+	case LogTF:
+		return 1 + math.Log(float64(count))
+	case AugmentedTF:
+		return 0.5 + 0.5*float64(count)/float64(maxCount)
+	case RelativeTF:
+		return float64(count) / float64(total)
+	}
+	return float64(count)
+}
+
+// idf weighs a word that occurs in df of n documents
+func (w Weighting) idf(n, df int) float64 {
+	switch w.IDF {
+	case SmoothIDF:
+		return math.Log(float64(1+n)/float64(1+df)) + 1
+	case ProbabilisticIDF:
+		if df >= n {
+			return 0
+		}
+		return math.Max(0, math.Log(float64(n-df)/float64(df)))
+	case NoIDF:
+		return 1
+	}
+	return math.Log(float64(n) / float64(df))
+}
+
+// sortScores orders scores from highest to lowest, then alphabetically
+func sortScores(scores []ScoredWord) {
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return scores[i].Word < scores[j].Word
+	})
+}
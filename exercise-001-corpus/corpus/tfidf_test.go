@@ -0,0 +1,77 @@
+package corpus
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCollection(t *testing.T) *Collection {
+	collection := NewCollection()
+	assert.Nil(t, collection.AddText("cats", "the cat sat on the mat with the cat"))
+	assert.Nil(t, collection.AddText("dogs", "the dog sat on the log"))
+	assert.Nil(t, collection.AddText("birds", "the bird sang"))
+	return collection
+}
+
+func TestCollection(t *testing.T) {
+	collection := newTestCollection(t)
+	assert.Equal(t, 3, collection.Len())
+	assert.Equal(t, []string{"cats", "dogs", "birds"}, collection.Names())
+	assert.Equal(t, 3, collection.DocumentFrequency("the"))
+	assert.Equal(t, 2, collection.DocumentFrequency("sat"))
+	assert.Equal(t, 0, collection.DocumentFrequency("fish"))
+
+	histogram, err := collection.Histogram("cats")
+	assert.Nil(t, err)
+	assert.Equal(t, KeyValPair{"the", 3}, histogram[0])
+
+	assert.NotNil(t, collection.AddText("cats", "again"))
+	_, err = collection.TFIDF("fish", Weighting{})
+	assert.NotNil(t, err)
+}
+
+func TestTFIDF(t *testing.T) {
+	collection := newTestCollection(t)
+
+	scores, err := collection.TFIDF("cats", Weighting{})
+	assert.Nil(t, err)
+	assert.Equal(t, "cat", scores[0].Word)
+	assert.InDelta(t, 2*math.Log(3), scores[0].Score, 1e-9)
+
+	// Words found in every document score zero with the standard IDF
+	last := scores[len(scores)-1]
+	assert.Equal(t, ScoredWord{"the", 0}, last)
+}
+
+func TestTFIDFWeighting(t *testing.T) {
+	collection := newTestCollection(t)
+
+	scores, _ := collection.TFIDF("cats", Weighting{TF: BooleanTF, IDF: NoIDF})
+	for _, score := range scores {
+		assert.Equal(t, 1.0, score.Score)
+	}
+
+	scores, _ = collection.TFIDF("cats", Weighting{TF: LogTF, IDF: SmoothIDF})
+	assert.InDelta(t, (1+math.Log(2))*(math.Log(4.0/2)+1), scores[0].Score, 1e-9)
+
+	scores, _ = collection.TFIDF("dogs", Weighting{TF: RelativeTF, IDF: ProbabilisticIDF})
+	assert.InDelta(t, math.Log(2)/6, scores[0].Score, 1e-9)
+
+	scores, _ = collection.TFIDF("cats", Weighting{TF: AugmentedTF, Normalize: true})
+	norm := 0.0
+	for _, score := range scores {
+		norm += score.Score * score.Score
+	}
+	assert.InDelta(t, 1.0, norm, 1e-9)
+}
+
+func TestCollectionOptions(t *testing.T) {
+	english, _ := BuiltinStopWords("en")
+	collection := NewCollection(WithStopWords(english), WithStemming())
+	assert.Nil(t, collection.AddText("a", "The runners were running"))
+	assert.Nil(t, collection.AddText("b", "Nobody runs"))
+	assert.Equal(t, 2, collection.DocumentFrequency("run"))
+	assert.Equal(t, 0, collection.DocumentFrequency("the"))
+}
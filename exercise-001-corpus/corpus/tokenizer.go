@@ -0,0 +1,352 @@
+package corpus
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// Token is a single word produced by a Tokenizer
+type Token struct {
+	Text   string
+	Offset int64 // byte offset of the first character in the input
+	Line   int   // line number of the first character, starting at 1
+}
+
+// Tokenizer splits a stream of text into word tokens
+type Tokenizer interface {
+	// Tokenize reads r until EOF and calls emit with every token in order
+	Tokenize(r io.Reader, emit func(Token)) error
+}
+
+// WithTokenizer replaces the default WordTokenizer used to split text into words
+func WithTokenizer(tokenizer Tokenizer) Option {
+	return func(cfg *config) {
+		if tokenizer != nil {
+			cfg.tokenizer = tokenizer
+		}
+	}
+}
+
+// LetterTokenizer splits text at every character that is not a letter and
+// lowercases the result. "don't" becomes "don" and "t", and numbers are dropped.
+type LetterTokenizer struct{}
+
+// Tokenize implements Tokenizer
+func (LetterTokenizer) Tokenize(r io.Reader, emit func(Token)) error {
+	rr := newLookaheadReader(r)
+	var word strings.Builder
+	var start Token
+
+	flush := func() {
+		if word.Len() > 0 {
+			start.Text = strings.ToLower(word.String())
+			emit(start)
+			word.Reset()
+		}
+	}
+
+	for {
+		offset, line := rr.offset, rr.line
+		c, err := rr.next()
+		if err != nil {
+			flush()
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		// Any non-letter character ends the current word
+		if unicode.IsLetter(c) {
+			if word.Len() == 0 {
+				start = Token{Offset: offset, Line: line}
+			}
+			word.WriteRune(c)
+		} else {
+			flush()
+		}
+	}
+}
+
+// WordTokenizer finds words using the word boundary rules of Unicode UAX #29.
+// Letters joined by apostrophes or periods ("don't", "e.g") stay one word,
+// as do numbers like "3.14" or "1,000". Only word-like segments are emitted;
+// punctuation, spaces and symbols are dropped. Ideographic characters are
+// emitted one at a time. The zero value folds words to lowercase.
+type WordTokenizer struct {
+	SplitContractions bool // split "don't" into "don" and "t"
+	JoinHyphens       bool // keep hyphenated compounds such as "e-mail" as one word
+	SkipNumbers       bool // drop tokens that contain no letters, like "42" or "3.14"
+	KeepCase          bool // don't fold words to lowercase
+}
+
+// Tokenize implements Tokenizer
+func (t WordTokenizer) Tokenize(r io.Reader, emit func(Token)) error {
+	rr := newLookaheadReader(r)
+	var word strings.Builder
+	var start Token
+	var prev wbClass
+	hasLetter := false
+
+	flush := func() {
+		if word.Len() > 0 && (hasLetter || !t.SkipNumbers) {
+			start.Text = word.String()
+			if !t.KeepCase {
+				start.Text = strings.ToLower(start.Text)
+			}
+			emit(start)
+		}
+		word.Reset()
+		prev = wbOther
+		hasLetter = false
+	}
+
+	for {
+		offset, line := rr.offset, rr.line
+		c, err := rr.next()
+		if err != nil {
+			flush()
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		class := t.class(c)
+
+		if word.Len() > 0 {
+			switch {
+			case class == wbExtend:
+				// WB4: marks and format characters stick to what precedes them
+				word.WriteRune(c)
+				continue
+			case joins(prev, class):
+				word.WriteRune(c)
+				prev = class
+				hasLetter = hasLetter || class.isLetter()
+				continue
+			case prev == wbHebrewLetter && class == wbSingleQuote:
+				// WB7a
+				word.WriteRune(c)
+				continue
+			case class.isMid():
+				// WB6/WB7 and WB11/WB12 need to see the character after the middle one
+				next, err := rr.peekPastExtend()
+				if err != nil && err != io.EOF {
+					return err
+				}
+				if err == nil && joinsAcross(prev, class, t.class(next)) {
+					word.WriteRune(c)
+					continue
+				}
+			}
+			flush()
+		}
+
+		// Start a new word, or skip the character if it can't begin one
+		switch class {
+		case wbALetter, wbHebrewLetter, wbKatakana, wbNumeric, wbExtendNumLet, wbIdeographic:
+			start = Token{Offset: offset, Line: line}
+			word.WriteRune(c)
+			prev = class
+			hasLetter = class.isLetter()
+		}
+	}
+}
+
+// wbClass is the Word_Break property of a character
+type wbClass int
+
+const (
+	wbOther wbClass = iota
+	wbALetter
+	wbHebrewLetter
+	wbKatakana
+	wbIdeographic
+	wbNumeric
+	wbExtendNumLet
+	wbExtend
+	wbMidLetter
+	wbMidNum
+	wbMidNumLet
+	wbSingleQuote
+	wbDoubleQuote
+	wbHyphen
+)
+
+// isLetter reports whether the class is made of letters
+func (c wbClass) isLetter() bool {
+	switch c {
+	case wbALetter, wbHebrewLetter, wbKatakana, wbIdeographic:
+		return true
+	}
+	return false
+}
+
+// isMid reports whether the class can join two parts of one word
+func (c wbClass) isMid() bool {
+	switch c {
+	case wbMidLetter, wbMidNum, wbMidNumLet, wbSingleQuote, wbDoubleQuote, wbHyphen:
+		return true
+	}
+	return false
+}
+
+// isAHLetter matches the AHLetter macro of UAX #29
+func (c wbClass) isAHLetter() bool {
+	return c == wbALetter || c == wbHebrewLetter
+}
+
+// class returns the Word_Break class of c, adjusted for the tokenizer options
+func (t WordTokenizer) class(c rune) wbClass {
+	switch c {
+	case '\'', '\u2019':
+		if t.SplitContractions {
+			return wbOther
+		}
+	case '-', '\u2010', '\u2011':
+		if t.JoinHyphens {
+			return wbHyphen
+		}
+		return wbOther
+	}
+	return wordBreakClass(c)
+}
+
+// wordBreakClass approximates the Word_Break property of c using the
+// categories and scripts in the unicode package
+func wordBreakClass(c rune) wbClass {
+	switch c {
+	case '"':
+		return wbDoubleQuote
+	case '\'':
+		return wbSingleQuote
+	case '.', '\u2018', '\u2019', '\u2024', '\ufe52', '\uff07', '\uff0e':
+		return wbMidNumLet
+	case ':', '\u00b7', '\u0387', '\u055f', '\u05f4', '\u2027', '\ufe13', '\ufe55', '\uff1a':
+		return wbMidLetter
+	case ',', ';', '\u037e', '\u0589', '\u060c', '\u060d', '\u066c', '\u07f8', '\u2044',
+		'\ufe10', '\ufe14', '\ufe50', '\ufe54', '\uff0c', '\uff1b':
+		return wbMidNum
+	case '\u200b':
+		// The zero width space is a format character but separates words
+		return wbOther
+	case '\u30fc', '\uff70':
+		// The prolonged sound mark is shared by Hiragana and Katakana
+		return wbKatakana
+	case '\u202f':
+		return wbExtendNumLet
+	}
+
+	switch {
+	case unicode.In(c, unicode.Mn, unicode.Me, unicode.Mc, unicode.Cf):
+		return wbExtend
+	case unicode.Is(unicode.Pc, c):
+		return wbExtendNumLet
+	case unicode.IsDigit(c):
+		return wbNumeric
+	case unicode.Is(unicode.Katakana, c):
+		return wbKatakana
+	case unicode.In(c, unicode.Han, unicode.Hiragana):
+		return wbIdeographic
+	case unicode.Is(unicode.Hebrew, c) && unicode.IsLetter(c):
+		return wbHebrewLetter
+	case unicode.IsLetter(c) || unicode.Is(unicode.Nl, c):
+		return wbALetter
+	}
+	return wbOther
+}
+
+// joins reports whether there is no word break between two adjacent
+// characters of the given classes (WB5, WB8-WB10, WB13-WB13b)
+func joins(prev, next wbClass) bool {
+	switch {
+	case prev.isAHLetter() && next.isAHLetter(), // WB5
+		prev == wbNumeric && next == wbNumeric,   // WB8
+		prev.isAHLetter() && next == wbNumeric,   // WB9
+		prev == wbNumeric && next.isAHLetter(),   // WB10
+		prev == wbKatakana && next == wbKatakana: // WB13
+		return true
+	case next == wbExtendNumLet: // WB13a
+		return prev.isAHLetter() || prev == wbNumeric || prev == wbKatakana || prev == wbExtendNumLet
+	case prev == wbExtendNumLet: // WB13b
+		return next.isAHLetter() || next == wbNumeric || next == wbKatakana
+	}
+	return false
+}
+
+// joinsAcross reports whether a middle character of class mid, between
+// prev and next, belongs to the word (WB6, WB7, WB7b, WB7c, WB11, WB12)
+func joinsAcross(prev, mid, next wbClass) bool {
+	switch {
+	case prev == wbHebrewLetter && mid == wbDoubleQuote && next == wbHebrewLetter:
+		return true
+	case prev.isAHLetter() && next.isAHLetter():
+		return mid == wbMidLetter || mid == wbMidNumLet || mid == wbSingleQuote || mid == wbHyphen
+	case prev == wbNumeric && next == wbNumeric:
+		return mid == wbMidNum || mid == wbMidNumLet || mid == wbSingleQuote || mid == wbHyphen
+	case mid == wbHyphen:
+		// Compounds such as "covid-19" mix letters and digits
+		return (prev.isAHLetter() || prev == wbNumeric) && (next.isAHLetter() || next == wbNumeric)
+	}
+	return false
+}
+
+// lookaheadReader reads runes, keeps track of their position and lets the
+// tokenizer peek at the ones that follow
+type lookaheadReader struct {
+	br      *bufio.Reader
+	pending []sizedRune
+	offset  int64 // byte offset of the rune returned by the next call to next
+	line    int   // line number of that rune
+}
+
+// sizedRune is a rune with its encoded length in the input
+type sizedRune struct {
+	c    rune
+	size int
+}
+
+// newLookaheadReader starts reading r at offset 0 on line 1
+func newLookaheadReader(r io.Reader) *lookaheadReader {
+	return &lookaheadReader{br: bufio.NewReader(r), line: 1}
+}
+
+// next returns the next rune
+func (rr *lookaheadReader) next() (rune, error) {
+	var sr sizedRune
+	if len(rr.pending) > 0 {
+		sr = rr.pending[0]
+		rr.pending = rr.pending[1:]
+	} else {
+		var err error
+		sr.c, sr.size, err = rr.br.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	rr.offset += int64(sr.size)
+	if sr.c == '\n' {
+		rr.line++
+	}
+	return sr.c, nil
+}
+
+// peekPastExtend returns the first upcoming rune that is not an extending
+// character, without consuming anything
+func (rr *lookaheadReader) peekPastExtend() (rune, error) {
+	for i := 0; ; i++ {
+		if i == len(rr.pending) {
+			c, size, err := rr.br.ReadRune()
+			if err != nil {
+				return 0, err
+			}
+			rr.pending = append(rr.pending, sizedRune{c, size})
+		}
+		if wordBreakClass(rr.pending[i].c) != wbExtend {
+			return rr.pending[i].c, nil
+		}
+	}
+}
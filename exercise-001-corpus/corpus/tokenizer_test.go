@@ -0,0 +1,79 @@
+package corpus
+
+import (
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// tokenize collects the text of every token produced by tokenizer
+func tokenize(t *testing.T, tokenizer Tokenizer, text string) []string {
+	var words []string
+	err := tokenizer.Tokenize(strings.NewReader(text), func(token Token) {
+		words = append(words, token.Text)
+	})
+	assert.Nil(t, err)
+	return words
+}
+
+func TestWordTokenizer(t *testing.T) {
+	text := "Don't send an e-mail, e.g. to O'Brien: it costs $3.14 (1,000 times)."
+	assert.Equal(t,
+		[]string{"don't", "send", "an", "e", "mail", "e.g", "to", "o'brien", "it", "costs", "3.14", "1,000", "times"},
+		tokenize(t, WordTokenizer{}, text))
+}
+
+func TestWordTokenizerOptions(t *testing.T) {
+	text := "Don't send an e-mail about COVID-19 at 3.14"
+	assert.Equal(t,
+		[]string{"Don", "t", "send", "an", "e-mail", "about", "COVID-19", "at"},
+		tokenize(t, WordTokenizer{SplitContractions: true, JoinHyphens: true, SkipNumbers: true, KeepCase: true}, text))
+}
+
+func TestWordTokenizerUnicode(t *testing.T) {
+	// Combining marks stay with their letter, ideographs are single words,
+	// and Katakana runs are kept together
+	assert.Equal(t, []string{"café", "東", "京", "コンピューター", "snake_case"},
+		tokenize(t, WordTokenizer{}, "Café! 東京 コンピューター snake_case"))
+	assert.Equal(t, []string{"צה\"ל"}, tokenize(t, WordTokenizer{}, "צה\"ל"))
+}
+
+func TestWordTokenizerTrailingPunctuation(t *testing.T) {
+	assert.Equal(t, []string{"end", "it's"}, tokenize(t, WordTokenizer{}, "end. 'it's'"))
+	assert.Equal(t, []string{"a", "b"}, tokenize(t, WordTokenizer{}, "a.́ b"))
+}
+
+func TestWordTokenizerError(t *testing.T) {
+	err := WordTokenizer{}.Tokenize(iotest.TimeoutReader(strings.NewReader("one two")), func(Token) {})
+	assert.Equal(t, iotest.ErrTimeout, err)
+}
+
+func TestLetterTokenizer(t *testing.T) {
+	assert.Equal(t, []string{"don", "t", "panic", "über"}, tokenize(t, LetterTokenizer{}, "Don't PANIC, 42 Über!"))
+}
+
+func TestAnalysisWithTokenizer(t *testing.T) {
+	result := Analysis("Don't, don't!", WithTokenizer(LetterTokenizer{}))
+	assert.Equal(t, Histogram{{"don", 2}, {"t", 2}}, result)
+
+	result = Analysis("Don't, don't!")
+	assert.Equal(t, Histogram{{"don't", 2}}, result)
+}
+
+func TestTokenPositions(t *testing.T) {
+	var tokens []Token
+	err := WordTokenizer{}.Tokenize(strings.NewReader("é b\n\nc"), func(token Token) {
+		tokens = append(tokens, token)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []Token{{"é", 0, 1}, {"b", 3, 1}, {"c", 6, 3}}, tokens)
+
+	tokens = nil
+	err = LetterTokenizer{}.Tokenize(strings.NewReader("a\nbc"), func(token Token) {
+		tokens = append(tokens, token)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []Token{{"a", 0, 1}, {"bc", 2, 2}}, tokens)
+}
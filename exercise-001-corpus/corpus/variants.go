@@ -0,0 +1,138 @@
+package corpus
+
+import "unicode/utf8"
+
+// VariantMerge records that the count of a word was moved to a more
+// frequent word that is probably its correct spelling
+type VariantMerge struct {
+	Variant  string `json:"variant"`
+	Into     string `json:"into"`
+	Count    int    `json:"count"`    // the count of the variant that was moved
+	Distance int    `json:"distance"` // the edit distance between the words
+}
+
+// GroupVariants merges rare spelling variants, such as OCR errors, into
+// frequent words within an edit distance of maxDistance. A word is merged
+// into a neighbour that occurs at least minRatio times as often; of several
+// neighbours, the closest and then the most frequent wins. Short words are
+// too easily confused with other real words, so the distance allowed is
+// below a third of a word's length and words of up to three letters are
+// never merged. The merges are returned with the new histogram, in the
+// order they were made, so they can be audited.
+func (h Histogram) GroupVariants(maxDistance int, minRatio float64) (Histogram, []VariantMerge) {
+	// Neighbours are compared by their own count, not including variants
+	// already merged into them, so the order of merges doesn't matter
+	original := h.Map()
+	counts := h.Map()
+	var merges []VariantMerge
+	var tree bkTree
+
+	// Frequent words come first, so every possible target is in the tree
+	// before its variants are looked at, and targets are never merged away
+	for _, keyVal := range h {
+		bound := min(maxDistance, (utf8.RuneCountInString(keyVal.Word)-1)/3)
+		best, bestDistance := "", 0
+		if bound > 0 {
+			tree.search(keyVal.Word, bound, func(word string, distance int) {
+				if float64(original[word]) < minRatio*float64(keyVal.Count) {
+					return
+				}
+				if best == "" || distance < bestDistance ||
+					distance == bestDistance && (original[word] > original[best] || original[word] == original[best] && word < best) {
+					best, bestDistance = word, distance
+				}
+			})
+		}
+
+		if best == "" {
+			tree.insert(keyVal.Word)
+			continue
+		}
+		counts[best] += keyVal.Count
+		delete(counts, keyVal.Word)
+		merges = append(merges, VariantMerge{Variant: keyVal.Word, Into: best, Count: keyVal.Count, Distance: bestDistance})
+	}
+	return newHistogram(counts), merges
+}
+
+// EditDistance returns the Levenshtein distance between a and b: the
+// fewest single-character insertions, deletions and substitutions that
+// turn one into the other
+func EditDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	row := make([]int, len(rb)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		diagonal := row[0]
+		row[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			diagonal, row[j] = row[j], min(row[j]+1, row[j-1]+1, diagonal+cost)
+		}
+	}
+	return row[len(rb)]
+}
+
+// bkTree is a Burkhard-Keller tree of words, which finds the words within
+// an edit distance without comparing the query with every word. Children
+// are keyed by their distance to the parent; by the triangle inequality,
+// only the children within the bound of the query's distance can match.
+type bkTree struct {
+	root *bkNode
+}
+
+type bkNode struct {
+	word     string
+	children map[int]*bkNode
+}
+
+// insert adds a word to the tree
+func (t *bkTree) insert(word string) {
+	if t.root == nil {
+		t.root = &bkNode{word: word}
+		return
+	}
+	node := t.root
+	for {
+		distance := EditDistance(word, node.word)
+		if distance == 0 {
+			return
+		}
+		child, ok := node.children[distance]
+		if !ok {
+			if node.children == nil {
+				node.children = make(map[int]*bkNode)
+			}
+			node.children[distance] = &bkNode{word: word}
+			return
+		}
+		node = child
+	}
+}
+
+// search calls fn with every word within bound of word and its distance
+func (t *bkTree) search(word string, bound int, fn func(word string, distance int)) {
+	if t.root == nil {
+		return
+	}
+	pending := []*bkNode{t.root}
+	for len(pending) > 0 {
+		node := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		distance := EditDistance(word, node.word)
+		if distance <= bound {
+			fn(node.word, distance)
+		}
+		for childDistance, child := range node.children {
+			if childDistance >= distance-bound && childDistance <= distance+bound {
+				pending = append(pending, child)
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package corpus
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditDistance(t *testing.T) {
+	cases := []struct {
+		a, b     string
+		distance int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"recieve", "receive", 2},
+		{"flaw", "lawn", 2},
+		{"über", "uber", 1},
+		{"same", "same", 0},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.distance, EditDistance(c.a, c.b), c.a+"/"+c.b)
+		assert.Equal(t, c.distance, EditDistance(c.b, c.a), c.b+"/"+c.a)
+	}
+}
+
+func TestBKTree(t *testing.T) {
+	words := []string{"book", "books", "cake", "boo", "boon", "cook", "cape", "cart", "receive", "book"}
+	var tree bkTree
+	for _, word := range words {
+		tree.insert(word)
+	}
+
+	// The tree finds the same words as comparing with every word
+	for _, query := range []string{"bool", "cape", "receive", "xyz", "caqe"} {
+		for bound := 0; bound <= 3; bound++ {
+			want := map[string]int{}
+			for _, word := range words {
+				if distance := EditDistance(query, word); distance <= bound {
+					want[word] = distance
+				}
+			}
+			got := map[string]int{}
+			tree.search(query, bound, func(word string, distance int) {
+				got[word] = distance
+			})
+			assert.Equal(t, want, got, fmt.Sprintf("%s within %d", query, bound))
+		}
+	}
+}
+
+func TestGroupVariants(t *testing.T) {
+	h := newHistogram(map[string]int{
+		"receive":  40,
+		"recieve":  3,
+		"recelve":  1,
+		"the":      100,
+		"she":      5, // too short to be a variant
+		"samurai":  30,
+		"samural":  2,
+		"sarnurai": 1,
+		"received": 10, // not rare enough next to "receive"
+		"robbers":  20,
+		"robbery":  10,
+	})
+
+	grouped, merges := h.GroupVariants(2, 5)
+	assert.Equal(t, []VariantMerge{
+		{Variant: "recieve", Into: "receive", Count: 3, Distance: 2},
+		{Variant: "samural", Into: "samurai", Count: 2, Distance: 1},
+		{Variant: "recelve", Into: "receive", Count: 1, Distance: 1},
+		{Variant: "sarnurai", Into: "samurai", Count: 1, Distance: 2},
+	}, merges)
+	assert.Equal(t, h.Total(), grouped.Total())
+	count, _ := grouped.Lookup("receive")
+	assert.Equal(t, 44, count)
+	count, _ = grouped.Lookup("samurai")
+	assert.Equal(t, 33, count)
+	for _, word := range []string{"she", "received", "robbery"} {
+		_, ok := grouped.Lookup(word)
+		assert.True(t, ok, word)
+	}
+
+	// A smaller bound only merges the closest variants
+	_, merges = h.GroupVariants(1, 5)
+	assert.Equal(t, 2, len(merges))
+
+	// Without a bound nothing changes
+	grouped, merges = h.GroupVariants(0, 5)
+	assert.Equal(t, h, grouped)
+	assert.Empty(t, merges)
+}
@@ -0,0 +1,93 @@
+package corpus
+
+import (
+	"strings"
+	"time"
+)
+
+// WindowedCounter counts the words of a stream of lines, such as a growing
+// log file, optionally only over the most recent lines or time span. Lines
+// are analyzed like AnalysisReader does, but n-grams don't span lines.
+type WindowedCounter struct {
+	cfg      *config
+	maxLines int           // 0 keeps every line
+	maxAge   time.Duration // 0 keeps lines of any age
+	counts   map[string]int
+	lines    []windowLine // lines still in the window, oldest first
+}
+
+// windowLine is a counted line that may leave the window later
+type windowLine struct {
+	at    time.Time
+	terms []string
+}
+
+// NewWindowedCounter returns a counter over the last maxLines lines and the
+// lines added within maxAge. A zero limit doesn't apply, so with both zero
+// every line is counted and no line is kept in memory.
+func NewWindowedCounter(maxLines int, maxAge time.Duration, opts ...Option) *WindowedCounter {
+	return &WindowedCounter{
+		cfg:      newConfig(opts),
+		maxLines: max(maxLines, 0),
+		maxAge:   max(maxAge, 0),
+		counts:   make(map[string]int),
+	}
+}
+
+// AddLine counts the words of a line added at the given time, and drops
+// the lines that no longer fit in the window
+func (w *WindowedCounter) AddLine(line string, at time.Time) {
+	windowed := w.maxLines > 0 || w.maxAge > 0
+	var terms []string
+
+	scanTerms(strings.NewReader(line), w.cfg, func(term string) {
+		w.counts[term]++
+		if windowed {
+			terms = append(terms, term)
+		}
+	})
+
+	if windowed {
+		w.lines = append(w.lines, windowLine{at: at, terms: terms})
+		w.Expire(at)
+	}
+}
+
+// Expire drops the lines that are older than the window at time now. Call
+// it before reading the counts when no lines have been added for a while.
+func (w *WindowedCounter) Expire(now time.Time) {
+	drop := 0
+	if w.maxLines > 0 && len(w.lines) > w.maxLines {
+		drop = len(w.lines) - w.maxLines
+	}
+	if w.maxAge > 0 {
+		for drop < len(w.lines) && now.Sub(w.lines[drop].at) > w.maxAge {
+			drop++
+		}
+	}
+
+	for _, line := range w.lines[:drop] {
+		for _, term := range line.terms {
+			if w.counts[term]--; w.counts[term] == 0 {
+				delete(w.counts, term)
+			}
+		}
+	}
+	w.lines = w.lines[drop:]
+
+	// Reuse the space of dropped lines once the slice has moved on enough
+	if cap(w.lines) > 2*len(w.lines)+64 {
+		w.lines = append([]windowLine(nil), w.lines...)
+	}
+}
+
+// Lines returns the number of lines in the window. It is always zero
+// without a window.
+func (w *WindowedCounter) Lines() int {
+	return len(w.lines)
+}
+
+// Histogram returns the counts of the words in the window
+func (w *WindowedCounter) Histogram() Histogram {
+	return newHistogram(w.counts)
+}
@@ -0,0 +1,41 @@
+package corpus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWindowedCounter(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Without limits every line counts and none is kept
+	all := NewWindowedCounter(0, 0)
+	all.AddLine("GET /index", start)
+	all.AddLine("GET /about", start.Add(time.Hour))
+	assert.Equal(t, Histogram{{"get", 2}, {"about", 1}, {"index", 1}}, all.Histogram())
+	assert.Equal(t, 0, all.Lines())
+
+	// Only the last two lines
+	lines := NewWindowedCounter(2, 0)
+	lines.AddLine("error disk full", start)
+	lines.AddLine("error timeout", start)
+	lines.AddLine("ok", start)
+	assert.Equal(t, Histogram{{"error", 1}, {"ok", 1}, {"timeout", 1}}, lines.Histogram())
+	assert.Equal(t, 2, lines.Lines())
+}
+
+func TestWindowedCounterAge(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	recent := NewWindowedCounter(0, 5*time.Minute, WithNGrams(2))
+	recent.AddLine("disk full", start)
+	recent.AddLine("disk full", start.Add(3*time.Minute))
+	recent.AddLine("timeout error", start.Add(6*time.Minute))
+	assert.Equal(t, Histogram{{"disk full", 1}, {"timeout error", 1}}, recent.Histogram())
+
+	// Lines also leave the window when nothing is added
+	recent.Expire(start.Add(20 * time.Minute))
+	assert.Equal(t, 0, len(recent.Histogram()))
+	assert.Equal(t, 0, recent.Lines())
+}
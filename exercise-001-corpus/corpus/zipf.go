@@ -0,0 +1,245 @@
+package corpus
+
+import (
+	"encoding/csv"
+	"io"
+	"math"
+	"strconv"
+)
+
+// growthStep is the factor between the token counts at which the
+// vocabulary growth curve is sampled, so long texts get evenly spaced
+// points on a log scale
+const growthStep = 1.05
+
+// ZipfFit is Zipf's law, count = C / rank^Exponent, fitted to a histogram
+// by least squares on the log-log rank/frequency plot. Natural language
+// usually has an exponent near 1.
+type ZipfFit struct {
+	Exponent float64 `json:"exponent"`
+	C        float64 `json:"c"`        // the predicted count of the top word
+	RSquared float64 `json:"rSquared"` // goodness of fit, 1 is perfect
+}
+
+// GrowthPoint is the vocabulary size after a number of tokens
+type GrowthPoint struct {
+	Tokens int `json:"tokens"`
+	Types  int `json:"types"`
+}
+
+// HeapsFit is Heaps' law, types = K * tokens^Beta, fitted to a vocabulary
+// growth curve. Beta is typically between 0.4 and 0.6 for English.
+type HeapsFit struct {
+	K        float64 `json:"k"`
+	Beta     float64 `json:"beta"`
+	RSquared float64 `json:"rSquared"`
+}
+
+// VocabularyReport describes how the words of a text are distributed. A
+// poor Zipf fit or a growth curve with flat stretches, where no new words
+// appear, can point to truncated or duplicated input.
+type VocabularyReport struct {
+	Tokens int           `json:"tokens"`
+	Types  int           `json:"types"`
+	Zipf   ZipfFit       `json:"zipf"`
+	Heaps  HeapsFit      `json:"heaps"`
+	Growth []GrowthPoint `json:"growth"`
+
+	// Histogram holds the ranked counts the fits were made on
+	Histogram Histogram `json:"-"`
+}
+
+// Vocabulary counts the terms of r like AnalysisReader does, recording how
+// the vocabulary grows along the way, and fits Zipf's and Heaps' laws
+func Vocabulary(r io.Reader, opts ...Option) (VocabularyReport, error) {
+	v := newVocabulary()
+	if err := scanTerms(r, newConfig(opts), v.add); err != nil {
+		return VocabularyReport{}, err
+	}
+	return v.report(), nil
+}
+
+// VocabularyCounter makes one VocabularyReport of several texts read one
+// after the other, as if they were a single text
+type VocabularyCounter struct {
+	cfg   *config
+	total *vocabulary
+}
+
+// NewVocabularyCounter returns an empty counter
+func NewVocabularyCounter(opts ...Option) *VocabularyCounter {
+	return &VocabularyCounter{cfg: newConfig(opts), total: newVocabulary()}
+}
+
+// AddReader reads a text and returns its own report. A text that fails
+// part-way is taken back out again, so it counts nowhere. N-grams never
+// run across texts.
+func (c *VocabularyCounter) AddReader(r io.Reader) (VocabularyReport, error) {
+	text := newVocabulary()
+	tokens, next, points := c.total.tokens, c.total.next, len(c.total.growth)
+	err := scanTerms(r, c.cfg, func(term string) {
+		text.add(term)
+		c.total.add(term)
+	})
+	if err != nil {
+		for term, count := range text.counts {
+			if c.total.counts[term] -= count; c.total.counts[term] == 0 {
+				delete(c.total.counts, term)
+			}
+		}
+		c.total.tokens, c.total.next, c.total.growth = tokens, next, c.total.growth[:points]
+		return VocabularyReport{}, err
+	}
+	return text.report(), nil
+}
+
+// Report returns the report of all texts added
+func (c *VocabularyCounter) Report() VocabularyReport {
+	return c.total.report()
+}
+
+// vocabulary counts terms and samples the growth curve as they come
+type vocabulary struct {
+	counts map[string]int
+	growth []GrowthPoint
+	tokens int
+	next   int // the token count of the next growth point
+}
+
+func newVocabulary() *vocabulary {
+	return &vocabulary{counts: make(map[string]int), next: 1}
+}
+
+func (v *vocabulary) add(term string) {
+	v.counts[term]++
+	v.tokens++
+	if v.tokens >= v.next {
+		v.growth = append(v.growth, GrowthPoint{Tokens: v.tokens, Types: len(v.counts)})
+		v.next = max(v.next+1, int(math.Ceil(float64(v.next)*growthStep)))
+	}
+}
+
+func (v *vocabulary) report() VocabularyReport {
+	// The curve always ends with the whole text; the sampled points are
+	// copied so more terms can still be added
+	growth := v.growth[:len(v.growth):len(v.growth)]
+	if v.tokens > 0 && growth[len(growth)-1].Tokens != v.tokens {
+		growth = append(growth, GrowthPoint{Tokens: v.tokens, Types: len(v.counts)})
+	}
+
+	histogram := newHistogram(v.counts)
+	return VocabularyReport{
+		Tokens:    v.tokens,
+		Types:     len(v.counts),
+		Zipf:      FitZipf(histogram),
+		Heaps:     FitHeaps(growth),
+		Growth:    growth,
+		Histogram: histogram,
+	}
+}
+
+// FitZipf fits Zipf's law to the counts of a histogram, which must be
+// ranked as Histogram orders them. Fewer than two words give a zero fit.
+func FitZipf(h Histogram) ZipfFit {
+	xs := make([]float64, len(h))
+	ys := make([]float64, len(h))
+	for i, keyVal := range h {
+		xs[i] = math.Log(float64(i + 1))
+		ys[i] = math.Log(float64(keyVal.Count))
+	}
+	slope, intercept, r2, ok := linearFit(xs, ys)
+	if !ok {
+		return ZipfFit{}
+	}
+	// 0 - slope rather than -slope, so a flat histogram has exponent 0, not -0
+	return ZipfFit{Exponent: 0 - slope, C: math.Exp(intercept), RSquared: r2}
+}
+
+// FitHeaps fits Heaps' law to a vocabulary growth curve. Fewer than two
+// points give a zero fit.
+func FitHeaps(growth []GrowthPoint) HeapsFit {
+	xs := make([]float64, len(growth))
+	ys := make([]float64, len(growth))
+	for i, point := range growth {
+		xs[i] = math.Log(float64(point.Tokens))
+		ys[i] = math.Log(float64(point.Types))
+	}
+	slope, intercept, r2, ok := linearFit(xs, ys)
+	if !ok {
+		return HeapsFit{}
+	}
+	return HeapsFit{K: math.Exp(intercept), Beta: slope, RSquared: r2}
+}
+
+// Predict returns the count the fit expects at a rank
+func (z ZipfFit) Predict(rank int) float64 {
+	return z.C / math.Pow(float64(rank), z.Exponent)
+}
+
+// Predict returns the vocabulary size the fit expects after tokens
+func (h HeapsFit) Predict(tokens int) float64 {
+	return h.K * math.Pow(float64(tokens), h.Beta)
+}
+
+// WriteRankFrequency writes the rank/frequency data of the report as CSV
+// for plotting: rank, word, count, relative frequency and the count Zipf's
+// law predicts
+func (v VocabularyReport) WriteRankFrequency(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"rank", "word", "count", "frequency", "zipf"})
+	for i, keyVal := range v.Histogram {
+		writer.Write([]string{
+			strconv.Itoa(i + 1), keyVal.Word, strconv.Itoa(keyVal.Count),
+			strconv.FormatFloat(float64(keyVal.Count)/float64(v.Tokens), 'g', 6, 64),
+			strconv.FormatFloat(v.Zipf.Predict(i+1), 'f', 2, 64),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteGrowth writes the vocabulary growth curve as CSV for plotting:
+// tokens, types and the types Heaps' law predicts
+func (v VocabularyReport) WriteGrowth(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"tokens", "types", "heaps"})
+	for _, point := range v.Growth {
+		writer.Write([]string{
+			strconv.Itoa(point.Tokens), strconv.Itoa(point.Types),
+			strconv.FormatFloat(v.Heaps.Predict(point.Tokens), 'f', 2, 64),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// linearFit fits y = slope*x + intercept by least squares and returns the
+// coefficient of determination. It fails for fewer than two distinct xs.
+func linearFit(xs, ys []float64) (slope, intercept, r2 float64, ok bool) {
+	n := float64(len(xs))
+	var sumX, sumY float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+	}
+	meanX, meanY := sumX/n, sumY/n
+
+	var sxx, sxy, syy float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		sxx += dx * dx
+		sxy += dx * dy
+		syy += dy * dy
+	}
+	if len(xs) < 2 || sxx == 0 {
+		return 0, 0, 0, false
+	}
+
+	slope = sxy / sxx
+	intercept = meanY - slope*meanX
+	r2 = 1
+	if syy > 0 {
+		r2 = sxy * sxy / (sxx * syy)
+	}
+	return slope, intercept, r2, true
+}
@@ -0,0 +1,119 @@
+package corpus
+
+import (
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFitZipf(t *testing.T) {
+	// Counts of exactly 1200/rank follow Zipf's law with exponent 1
+	var h Histogram
+	for rank := 1; rank <= 10; rank++ {
+		h = append(h, KeyValPair{Word: strings.Repeat("w", rank), Count: 1200 / rank})
+	}
+	fit := FitZipf(h)
+	assert.InDelta(t, 1, fit.Exponent, 0.01)
+	assert.InDelta(t, 1200, fit.C, 10)
+	assert.InDelta(t, 1, fit.RSquared, 0.001)
+	assert.InDelta(t, 600, fit.Predict(2), 10)
+
+	assert.Equal(t, ZipfFit{}, FitZipf(Histogram{{Word: "a", Count: 3}}))
+	assert.Equal(t, ZipfFit{}, FitZipf(nil))
+
+	// A flat histogram has exponent 0, which must not be encoded as -0
+	flat := FitZipf(Histogram{{Word: "a", Count: 2}, {Word: "b", Count: 2}, {Word: "c", Count: 2}})
+	assert.Equal(t, 0.0, flat.Exponent)
+	assert.False(t, math.Signbit(flat.Exponent))
+}
+
+func TestFitHeaps(t *testing.T) {
+	var growth []GrowthPoint
+	for _, tokens := range []int{100, 400, 1600, 6400} {
+		growth = append(growth, GrowthPoint{Tokens: tokens, Types: int(5 * math.Sqrt(float64(tokens)))})
+	}
+	fit := FitHeaps(growth)
+	assert.InDelta(t, 0.5, fit.Beta, 1e-9)
+	assert.InDelta(t, 5, fit.K, 1e-9)
+	assert.InDelta(t, 1, fit.RSquared, 1e-9)
+	assert.InDelta(t, 50, fit.Predict(100), 1e-9)
+
+	assert.Equal(t, HeapsFit{}, FitHeaps(growth[:1]))
+}
+
+func TestVocabulary(t *testing.T) {
+	report, err := Vocabulary(strings.NewReader("the cat and the dog and the bird"))
+	assert.Nil(t, err)
+	assert.Equal(t, 8, report.Tokens)
+	assert.Equal(t, 5, report.Types)
+	assert.Equal(t, "the", report.Histogram[0].Word)
+	assert.Equal(t, GrowthPoint{Tokens: 1, Types: 1}, report.Growth[0])
+	assert.Equal(t, GrowthPoint{Tokens: 8, Types: 5}, report.Growth[len(report.Growth)-1])
+	assert.Greater(t, report.Zipf.Exponent, 0.0)
+
+	empty, err := Vocabulary(strings.NewReader(""))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, empty.Tokens)
+	assert.Empty(t, empty.Growth)
+}
+
+func TestVocabularyBook(t *testing.T) {
+	book := mustRead(t, "../7oldsamr.txt")
+	report, err := Vocabulary(strings.NewReader(book))
+	assert.Nil(t, err)
+	// A text this short has few repeated words, so both curves are flatter
+	// and steeper than for a large corpus
+	assert.InDelta(t, 0.75, report.Zipf.Exponent, 0.25)
+	assert.Greater(t, report.Zipf.RSquared, 0.9)
+	assert.InDelta(t, 0.8, report.Heaps.Beta, 0.15)
+	assert.Greater(t, report.Heaps.RSquared, 0.95)
+
+	// A text repeated adds tokens but no types, so the curve flattens
+	doubled, err := Vocabulary(strings.NewReader(book + "\n" + book))
+	assert.Nil(t, err)
+	assert.Equal(t, report.Types, doubled.Types)
+	assert.Less(t, doubled.Heaps.Beta, report.Heaps.Beta)
+}
+
+func TestVocabularyCounter(t *testing.T) {
+	counter := NewVocabularyCounter()
+	first, err := counter.AddReader(strings.NewReader("the cat and the dog"))
+	assert.Nil(t, err)
+	assert.Equal(t, 5, first.Tokens)
+
+	// A text that fails part-way is taken back out of the total
+	_, err = counter.AddReader(io.MultiReader(strings.NewReader("lost words and more the "), iotest.ErrReader(io.ErrUnexpectedEOF)))
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+
+	second, err := counter.AddReader(strings.NewReader("and the bird"))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, second.Types)
+
+	// The total is the same as for the texts read as one
+	want, err := Vocabulary(strings.NewReader("the cat and the dog and the bird"))
+	assert.Nil(t, err)
+	assert.Equal(t, want, counter.Report())
+}
+
+func TestWriteRankFrequency(t *testing.T) {
+	report, err := Vocabulary(strings.NewReader("b a b c b a"))
+	assert.Nil(t, err)
+
+	var rankData strings.Builder
+	assert.Nil(t, report.WriteRankFrequency(&rankData))
+	lines := strings.Split(strings.TrimSpace(rankData.String()), "\n")
+	assert.Equal(t, "rank,word,count,frequency,zipf", lines[0])
+	assert.Equal(t, 4, len(lines))
+	assert.True(t, strings.HasPrefix(lines[1], "1,b,3,0.5,"), lines[1])
+	assert.True(t, strings.HasPrefix(lines[3], "3,c,1,0.166667,"), lines[3])
+
+	var growth strings.Builder
+	assert.Nil(t, report.WriteGrowth(&growth))
+	lines = strings.Split(strings.TrimSpace(growth.String()), "\n")
+	assert.Equal(t, "tokens,types,heaps", lines[0])
+	assert.True(t, strings.HasPrefix(lines[len(lines)-1], "6,3,"), lines[len(lines)-1])
+}
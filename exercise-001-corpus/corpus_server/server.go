@@ -0,0 +1,384 @@
+// Command corpus_server serves word statistics over HTTP.
+//
+// Text is POSTed to /analyze, either as the raw request body or as files
+// in a multipart/form-data upload, and the ranked histogram comes back as
+// JSON. Query parameters select the analysis:
+//
+//	n=2             also count phrases of 2 words
+//	top=20          only return the 20 most frequent entries
+//	stopwords=en    leave out the built-in English stop words
+//	stem=true       count word stems
+//	stats=true      add readability statistics
+//
+// The request charset is honoured, and HTML and Markdown bodies are
+// reduced to their text first.
+package main
+
+import (
+	"corpus/corpus"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "listen on `address`")
+	maxBytes := flag.Int64("max-bytes", 32<<20, "reject requests with bodies over `n` bytes")
+	flag.Parse()
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           newServer(*maxBytes),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Printf("listening on %s", *addr)
+	log.Fatal(server.ListenAndServe())
+}
+
+// server handles the analysis requests
+type server struct {
+	maxBytes int64
+}
+
+// newServer returns the handler of all routes
+func newServer(maxBytes int64) http.Handler {
+	s := &server{maxBytes: maxBytes}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/analyze", s.analyze)
+	return mux
+}
+
+// analysisResponse is the JSON body of a successful analysis
+type analysisResponse struct {
+	Total  int              `json:"total"`
+	Words  corpus.Histogram `json:"words"`
+	NGrams *ngramResponse   `json:"ngrams,omitempty"`
+	Stats  *corpus.Stats    `json:"stats,omitempty"`
+}
+
+// ngramResponse is the phrase histogram requested with n
+type ngramResponse struct {
+	N       int              `json:"n"`
+	Total   int              `json:"total"`
+	Phrases corpus.Histogram `json:"phrases"`
+}
+
+// httpError is an error with the status code to respond with
+type httpError struct {
+	status int
+	err    error
+}
+
+func (e *httpError) Error() string {
+	return e.err.Error()
+}
+
+func (e *httpError) Unwrap() error {
+	return e.err
+}
+
+// errorf returns an httpError with a formatted message
+func errorf(status int, format string, args ...any) error {
+	return &httpError{status: status, err: fmt.Errorf(format, args...)}
+}
+
+// analyze streams the posted text through the requested analyses at once,
+// so the text is read a single time and never held in memory
+func (s *server) analyze(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, r, errorf(http.StatusMethodNotAllowed, "use POST to send text"))
+		return
+	}
+
+	params, err := parseParams(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	body := http.MaxBytesReader(w, r.Body, s.maxBytes)
+
+	var response analysisResponse
+	analyses := []func(io.Reader) error{
+		func(text io.Reader) (err error) {
+			response.Words, err = corpus.AnalysisReader(text, params.opts...)
+			return err
+		},
+	}
+	if params.n > 1 {
+		response.NGrams = &ngramResponse{N: params.n}
+		analyses = append(analyses, func(text io.Reader) (err error) {
+			opts := append([]corpus.Option{corpus.WithNGrams(params.n)}, params.opts...)
+			response.NGrams.Phrases, err = corpus.AnalysisReader(text, opts...)
+			return err
+		})
+	}
+	if params.stats {
+		analyses = append(analyses, func(text io.Reader) error {
+			stats, err := corpus.StatisticsReader(text, params.opts...)
+			response.Stats = &stats
+			return err
+		})
+	}
+
+	err = teeAnalyses(func(w io.Writer) error { return copyText(w, r, body) }, analyses...)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response.Total = response.Words.Total()
+	response.Words = nonNil(response.Words.TopN(params.top))
+	if response.NGrams != nil {
+		response.NGrams.Total = response.NGrams.Phrases.Total()
+		response.NGrams.Phrases = nonNil(response.NGrams.Phrases.TopN(params.top))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(response)
+}
+
+// analysisParams are the options of a request
+type analysisParams struct {
+	opts  []corpus.Option
+	n     int
+	top   int
+	stats bool
+}
+
+// parseParams reads the query parameters of an analysis request
+func parseParams(r *http.Request) (analysisParams, error) {
+	query := r.URL.Query()
+	params := analysisParams{n: 1, top: -1}
+
+	intParam := func(name string, min int, value *int) error {
+		if text := query.Get(name); text != "" {
+			n, err := strconv.Atoi(text)
+			if err != nil || n < min {
+				return errorf(http.StatusBadRequest, "%s must be a number of at least %d", name, min)
+			}
+			*value = n
+		}
+		return nil
+	}
+	boolParam := func(name string, value *bool) error {
+		if text := query.Get(name); text != "" {
+			b, err := strconv.ParseBool(text)
+			if err != nil {
+				return errorf(http.StatusBadRequest, "%s must be true or false", name)
+			}
+			*value = b
+		}
+		return nil
+	}
+
+	var stem bool
+	for _, err := range []error{
+		intParam("n", 1, &params.n),
+		intParam("top", 0, &params.top),
+		boolParam("stem", &stem),
+		boolParam("stats", &params.stats),
+	} {
+		if err != nil {
+			return params, err
+		}
+	}
+
+	if stem {
+		params.opts = append(params.opts, corpus.WithStemming())
+	}
+	if language := query.Get("stopwords"); language != "" {
+		stopWords, err := corpus.BuiltinStopWords(language)
+		if err != nil {
+			return params, &httpError{http.StatusBadRequest, err}
+		}
+		params.opts = append(params.opts, corpus.WithStopWords(stopWords))
+	}
+	return params, nil
+}
+
+// copyText writes the text of a request body to w. A multipart upload
+// contributes every file and every field named "text", read part by part
+// so large uploads are streamed rather than buffered.
+func copyText(w io.Writer, r *http.Request, body io.Reader) error {
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil && r.Header.Get("Content-Type") != "" {
+		return errorf(http.StatusUnsupportedMediaType, "invalid content type: %v", err)
+	}
+	if mediaType != "multipart/form-data" {
+		return copyDecoded(w, body, "", mediaType, params["charset"])
+	}
+
+	r.Body = io.NopCloser(body)
+	parts, err := r.MultipartReader()
+	if err != nil {
+		return &httpError{http.StatusBadRequest, err}
+	}
+	found := false
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return &httpError{http.StatusBadRequest, err}
+		}
+		if part.FileName() == "" && part.FormName() != "text" {
+			part.Close()
+			continue
+		}
+
+		partType, partParams, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		err = copyDecoded(w, part, part.FileName(), partType, partParams["charset"])
+		part.Close()
+		if err != nil {
+			return err
+		}
+		found = true
+
+		// A blank line keeps sentences from running across files
+		io.WriteString(w, "\n\n")
+	}
+	if !found {
+		return errorf(http.StatusBadRequest, "the upload has no files and no text field")
+	}
+	return nil
+}
+
+// textFormats maps the accepted media types to document formats. An empty
+// media type is taken for plain text, and the format of generic binary
+// data is detected.
+var textFormats = map[string]corpus.Format{
+	"":                         corpus.FormatText,
+	"text/plain":               corpus.FormatText,
+	"application/octet-stream": "",
+	"text/html":                corpus.FormatHTML,
+	"application/xhtml+xml":    corpus.FormatHTML,
+	"text/markdown":            corpus.FormatMarkdown,
+	"application/gzip":         corpus.FormatGzip,
+	"application/epub+zip":     corpus.FormatEPUB,
+}
+
+// copyDecoded writes the text of a document of the given media type to w.
+// The file name, if any, helps to detect the format of generic uploads.
+func copyDecoded(w io.Writer, r io.Reader, name, mediaType, charset string) error {
+	format, ok := textFormats[mediaType]
+	if !ok {
+		return errorf(http.StatusUnsupportedMediaType, "unsupported content type %q", mediaType)
+	}
+	decoder := corpus.Decoder{Format: format}
+	if charset != "" {
+		encoding, err := corpus.ParseEncoding(charset)
+		if err != nil {
+			return errorf(http.StatusUnsupportedMediaType, "unsupported charset %q", charset)
+		}
+		decoder.Encoding = encoding
+	}
+
+	text, err := decoder.Decode(r, name)
+	if err != nil {
+		return err
+	}
+	if closer, ok := text.(io.Closer); ok {
+		defer closer.Close()
+	}
+	_, err = io.Copy(w, text)
+	return err
+}
+
+// teeAnalyses runs every analysis on its own copy of the text written by
+// produce. The analyses run concurrently while the text is produced once.
+func teeAnalyses(produce func(w io.Writer) error, analyses ...func(io.Reader) error) error {
+	errs := make([]error, len(analyses))
+	writers := make([]io.Writer, len(analyses))
+	pipes := make([]*io.PipeWriter, len(analyses))
+	var wg sync.WaitGroup
+
+	for i, analysis := range analyses {
+		pr, pw := io.Pipe()
+		writers[i], pipes[i] = pw, pw
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = analysis(pr)
+			// An analysis that stops early must not block the others
+			pr.CloseWithError(errors.New("analysis stopped reading"))
+		}()
+	}
+
+	err := produce(io.MultiWriter(writers...))
+	for _, pw := range pipes {
+		pw.CloseWithError(err)
+	}
+	wg.Wait()
+
+	// The producer's error explains the analyses' errors, so it comes first
+	if err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
+
+// writeError responds with the status of err as JSON, or as plain text to
+// clients that prefer it
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusInternalServerError
+	var httpErr *httpError
+	var maxBytesErr *http.MaxBytesError
+	var decodeErr *corpus.DecodeError
+	switch {
+	case errors.As(err, &httpErr):
+		status = httpErr.status
+	case errors.As(err, &maxBytesErr):
+		status = http.StatusRequestEntityTooLarge
+		err = fmt.Errorf("request body is larger than %d bytes", maxBytesErr.Limit)
+	case errors.As(err, &decodeErr):
+		status = http.StatusUnprocessableEntity
+	}
+
+	if prefersText(r.Header.Get("Accept")) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(status)
+		fmt.Fprintln(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(struct {
+		Error  string `json:"error"`
+		Status int    `json:"status"`
+	}{err.Error(), status})
+}
+
+// prefersText reports whether an Accept header asks for plain text rather
+// than JSON. JSON is the default.
+func prefersText(accept string) bool {
+	for _, item := range strings.Split(accept, ",") {
+		mediaType, _, _ := mime.ParseMediaType(strings.TrimSpace(item))
+		switch mediaType {
+		case "application/json", "*/*", "application/*":
+			return false
+		case "text/plain", "text/*":
+			return true
+		}
+	}
+	return false
+}
+
+// nonNil returns an empty histogram instead of nil, so it is encoded as []
+func nonNil(h corpus.Histogram) corpus.Histogram {
+	if h == nil {
+		return corpus.Histogram{}
+	}
+	return h
+}
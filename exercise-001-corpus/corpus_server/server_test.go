@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// post sends body to the test server and decodes a JSON response into v
+func post(t *testing.T, handler http.Handler, url, contentType string, body []byte, v any) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if v != nil {
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), v), rec.Body.String())
+	}
+	return rec
+}
+
+func TestAnalyzeText(t *testing.T) {
+	handler := newServer(1 << 20)
+	var response analysisResponse
+	rec := post(t, handler, "/analyze?top=2", "text/plain", []byte("The cat sat on the mat. The end."), &response)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, 8, response.Total)
+	assert.Equal(t, "the", response.Words[0].Word)
+	assert.Equal(t, 3, response.Words[0].Count)
+	assert.Equal(t, 2, len(response.Words))
+	assert.Nil(t, response.NGrams)
+	assert.Nil(t, response.Stats)
+}
+
+func TestAnalyzeNGramsAndStats(t *testing.T) {
+	handler := newServer(1 << 20)
+	var response analysisResponse
+	rec := post(t, handler, "/analyze?n=2&stats=true&stopwords=en", "", []byte("The old samurai. The old robbers."), &response)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "old", response.Words[0].Word)
+	assert.Equal(t, 2, response.NGrams.N)
+	assert.Equal(t, "old robbers", response.NGrams.Phrases[0].Word)
+	assert.Equal(t, 2, response.NGrams.Total) // no "samurai old" across the sentence end
+
+	// Statistics describe the text as written, stop words included
+	assert.Equal(t, 2, response.Stats.Sentences)
+	assert.Equal(t, 6, response.Stats.Words)
+}
+
+func TestAnalyzeMultipart(t *testing.T) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("comment", "ignored field")
+	form.WriteField("text", "seven samurai")
+	file, _ := form.CreateFormFile("upload", "a.txt")
+	file.Write([]byte("old samurai"))
+	file, _ = form.CreateFormFile("upload", "b.html")
+	file.Write([]byte("<p>old <b>robbers</b></p>"))
+	form.Close()
+
+	var response analysisResponse
+	rec := post(t, newServer(1<<20), "/analyze", form.FormDataContentType(), body.Bytes(), &response)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 6, response.Total)
+	count, _ := response.Words.Lookup("samurai")
+	assert.Equal(t, 2, count)
+	_, ok := response.Words.Lookup("ignored")
+	assert.False(t, ok)
+
+	// An upload without any text is an error
+	body.Reset()
+	form = multipart.NewWriter(&body)
+	form.WriteField("comment", "nothing to count")
+	form.Close()
+	rec = post(t, newServer(1<<20), "/analyze", form.FormDataContentType(), body.Bytes(), nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestAnalyzeEncodings(t *testing.T) {
+	var response analysisResponse
+	rec := post(t, newServer(1<<20), "/analyze", "text/plain; charset=iso-8859-1", []byte("fa\xe7ade"), &response)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "façade", response.Words[0].Word)
+
+	var failure struct {
+		Error  string `json:"error"`
+		Status int    `json:"status"`
+	}
+	rec = post(t, newServer(1<<20), "/analyze", "text/plain; charset=utf-8", []byte("fa\xe7ade"), &failure)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, failure.Error, "offset 2")
+}
+
+func TestAnalyzeErrors(t *testing.T) {
+	handler := newServer(16)
+	var failure struct {
+		Error  string `json:"error"`
+		Status int    `json:"status"`
+	}
+
+	rec := post(t, handler, "/analyze", "text/plain", []byte(strings.Repeat("samurai ", 10)), &failure)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, failure.Status)
+
+	rec = post(t, handler, "/analyze", "image/png", []byte("png"), &failure)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+	assert.Contains(t, failure.Error, "image/png")
+
+	rec = post(t, handler, "/analyze?n=zero", "text/plain", []byte("x"), &failure)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = post(t, handler, "/analyze?stopwords=klingon", "text/plain", []byte("x"), &failure)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/analyze", nil)
+	req.Header.Set("Accept", "text/plain")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "POST", rec.Header().Get("Allow"))
+	assert.Equal(t, "text/plain; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "use POST to send text\n", rec.Body.String())
+}
+
+func TestPrefersText(t *testing.T) {
+	assert.False(t, prefersText(""))
+	assert.False(t, prefersText("application/json, text/plain"))
+	assert.True(t, prefersText("text/plain;q=0.9, application/json;q=0.8"))
+	assert.False(t, prefersText("*/*"))
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"corpus/corpus"
+	"fmt"
+	"io"
+)
+
+// dedupeFlags configure the -dedupe option
+type dedupeFlags struct {
+	enabled   bool
+	threshold float64
+}
+
+// duplicateFile is a file left out as a near-duplicate of an earlier one
+type duplicateFile struct {
+	Path       string
+	Of         string
+	Similarity float64
+}
+
+// newIndex returns the index files are checked against, so the threshold
+// is validated before any file is read
+func (f *dedupeFlags) newIndex() (*corpus.LSHIndex, error) {
+	return corpus.NewLSHIndex(corpus.DefaultSignatureSize, f.threshold)
+}
+
+// dropDuplicates removes the results of files that are near-duplicates of
+// a file earlier in the list or already in the index, so the first of
+// every group is counted. Failed results are kept to be reported.
+func dropDuplicates(results []fileResult, index *corpus.LSHIndex) ([]fileResult, []duplicateFile, error) {
+	var kept []fileResult
+	var dropped []duplicateFile
+	for _, result := range results {
+		if result.Err == nil {
+			if found := index.Query(result.Signature); len(found) > 0 {
+				dropped = append(dropped, duplicateFile{Path: result.Path, Of: found[0].Name, Similarity: found[0].Similarity})
+				continue
+			}
+			if err := index.Add(result.Path, result.Signature); err != nil {
+				return nil, nil, err
+			}
+		}
+		kept = append(kept, result)
+	}
+	return kept, dropped, nil
+}
+
+// reportDuplicates lists the files left out by -dedupe
+func reportDuplicates(w io.Writer, dropped []duplicateFile) {
+	if len(dropped) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Skipped %d near-duplicate file(s):\n", len(dropped))
+	for _, duplicate := range dropped {
+		fmt.Fprintf(w, "  %s: %.0f%% similar to %s\n", duplicate.Path, 100*duplicate.Similarity, duplicate.Of)
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"corpus/corpus"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// fileResult is the outcome of analyzing a single input file
+type fileResult struct {
+	Path      string
+	Histogram corpus.Histogram
+	Signature corpus.Signature // only with -dedupe
+	Err       error
+}
+
+// expandInputs turns the command-line arguments into a list of files.
+// Arguments may be plain files, "-" for stdin, glob patterns or
+// directories, which are walked recursively. Arguments that can't be
+// expanded are returned as failed results.
+func expandInputs(args []string) ([]string, []fileResult) {
+	var paths []string
+	var failed []fileResult
+
+	for _, arg := range args {
+		if arg == "-" {
+			paths = append(paths, arg)
+			continue
+		}
+
+		// Expand glob patterns first; a pattern without matches is an error
+		matches := []string{arg}
+		if strings.ContainsAny(arg, "*?[") {
+			var err error
+			matches, err = filepath.Glob(arg)
+			if err == nil && len(matches) == 0 {
+				err = fs.ErrNotExist
+			}
+			if err != nil {
+				failed = append(failed, fileResult{Path: arg, Err: err})
+				continue
+			}
+		}
+
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil || !info.IsDir() {
+				// Missing files are reported when they are opened
+				paths = append(paths, match)
+				continue
+			}
+
+			err = filepath.WalkDir(match, func(path string, entry fs.DirEntry, err error) error {
+				if err != nil {
+					failed = append(failed, fileResult{Path: path, Err: err})
+					return nil
+				}
+				if entry.Type().IsRegular() {
+					paths = append(paths, path)
+				}
+				return nil
+			})
+			if err != nil {
+				failed = append(failed, fileResult{Path: match, Err: err})
+			}
+		}
+	}
+	return paths, failed
+}
+
+// analyzeFiles analyzes every path with a pool of workers goroutines, and
+// computes the MinHash signature of every file with sign. Results are
+// returned in the same order as paths.
+func analyzeFiles(paths []string, workers int, input inputReader, sign bool, opts []corpus.Option) []fileResult {
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make([]fileResult, len(paths))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = analyzeFile(paths[i], input, sign, opts)
+			}
+		}()
+	}
+
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// analyzeFile streams a single file, or stdin for "-", through the analyzer.
+// With sign, the text is also copied to the MinHash signature as it is read.
+func analyzeFile(path string, in inputReader, sign bool, opts []corpus.Option) fileResult {
+	result := fileResult{Path: path}
+	input, err := in.open(path)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer input.Close()
+
+	if !sign {
+		result.Histogram, result.Err = corpus.AnalysisReader(input, opts...)
+		return result
+	}
+
+	pr, pw := io.Pipe()
+	done := make(chan error)
+	go func() {
+		var err error
+		result.Signature, err = corpus.MinHash(pr, corpus.DefaultShingleSize, corpus.DefaultSignatureSize, opts...)
+		pr.CloseWithError(err)
+		done <- err
+	}()
+	result.Histogram, result.Err = corpus.AnalysisReader(io.TeeReader(input, pw), opts...)
+	pw.CloseWithError(result.Err)
+	if err := <-done; result.Err == nil {
+		result.Err = err
+	}
+	return result
+}
+
+// inputReader opens input files and decodes them into plain text
+type inputReader struct {
+	format   string // one of corpus.Formats, or "auto" to detect it per file
+	encoding string // a name accepted by corpus.ParseEncoding, or "auto"
+}
+
+// decodedInput is the text of an input file. Closing it stops the decoder
+// and closes the file.
+type decodedInput struct {
+	io.Reader
+	file *os.File
+}
+
+// Close implements io.Closer. Stdin is left open.
+func (d decodedInput) Close() error {
+	if closer, ok := d.Reader.(io.Closer); ok {
+		closer.Close()
+	}
+	if d.file == os.Stdin {
+		return nil
+	}
+	return d.file.Close()
+}
+
+// SourceOffset implements corpus.SourceMapper for the decoded text
+func (d decodedInput) SourceOffset(offset int64) int64 {
+	if mapper, ok := d.Reader.(corpus.SourceMapper); ok {
+		return mapper.SourceOffset(offset)
+	}
+	return -1
+}
+
+// open opens path for reading, with "-" meaning stdin, and decodes it
+func (in inputReader) open(path string) (io.ReadCloser, error) {
+	file := os.Stdin
+	if path != "-" {
+		var err error
+		if file, err = os.Open(path); err != nil {
+			return nil, err
+		}
+	}
+
+	// Detection is left to the decoder for anything set to auto
+	var decoder corpus.Decoder
+	if in.format != "auto" {
+		decoder.Format = corpus.Format(in.format)
+	}
+	if in.encoding != "auto" {
+		decoder.Encoding = corpus.Encoding(in.encoding)
+	}
+
+	text, err := decoder.Decode(file, path)
+	if err != nil {
+		decodedInput{file: file}.Close()
+		return nil, err
+	}
+	return decodedInput{Reader: text, file: file}, nil
+}
+
+// mergeResults adds up the histograms of all successful results
+func mergeResults(results []fileResult) corpus.Histogram {
+	var histograms []corpus.Histogram
+	for _, result := range results {
+		if result.Err == nil {
+			histograms = append(histograms, result.Histogram)
+		}
+	}
+	return corpus.Histogram{}.Merge(histograms...)
+}
+
+// fatal prints an error that stops the run before any output to stderr,
+// and exits with a non-zero status so scripts can detect it
+func fatal(args ...any) {
+	fmt.Fprintln(os.Stderr, args...)
+	os.Exit(1)
+}
+
+// reportFailures prints the files that could not be read and exits with
+// a non-zero status if there were any
+func reportFailures(failed []fileResult) {
+	if len(failed) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Error reading %d file(s):\n", len(failed))
+	for _, result := range failed {
+		fmt.Fprintf(os.Stderr, "  %s: %v\n", result.Path, result.Err)
+	}
+	os.Exit(1)
+}
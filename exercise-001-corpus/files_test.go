@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeFiles creates files with the given contents under dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.Nil(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+func TestExpandInputs(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.txt":         "a",
+		"b.md":          "b",
+		"sub/c.txt":     "c",
+		"sub/deep/d.md": "d",
+	})
+
+	// Directories are walked in lexical order
+	paths, failed := expandInputs([]string{dir})
+	assert.Empty(t, failed)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "a.txt"),
+		filepath.Join(dir, "b.md"),
+		filepath.Join(dir, "sub", "c.txt"),
+		filepath.Join(dir, "sub", "deep", "d.md"),
+	}, paths)
+
+	// Globs, stdin and missing files, in the order of the arguments
+	missing := filepath.Join(dir, "missing.txt")
+	paths, failed = expandInputs([]string{filepath.Join(dir, "*.txt"), "-", missing, filepath.Join(dir, "sub", "*")})
+	assert.Empty(t, failed)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "a.txt"),
+		"-",
+		missing,
+		filepath.Join(dir, "sub", "c.txt"),
+		filepath.Join(dir, "sub", "deep", "d.md"),
+	}, paths)
+
+	// Patterns that match nothing or are malformed are reported, and the
+	// other arguments are still expanded
+	paths, failed = expandInputs([]string{filepath.Join(dir, "*.html"), "[", filepath.Join(dir, "b.md")})
+	assert.Equal(t, []string{filepath.Join(dir, "b.md")}, paths)
+	if assert.Equal(t, 2, len(failed)) {
+		assert.True(t, errors.Is(failed[0].Err, fs.ErrNotExist))
+		assert.Equal(t, "[", failed[1].Path)
+		assert.NotNil(t, failed[1].Err)
+	}
+}
+
+func TestAnalyzeFiles(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for i := 0; i < 20; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("%02d.txt", i))
+		writeFiles(t, dir, map[string]string{filepath.Base(path): fmt.Sprintf("word%d word%d", i, i)})
+		paths = append(paths, path)
+	}
+	missing := filepath.Join(dir, "missing.txt")
+	paths = append(paths[:5], append([]string{missing}, paths[5:]...)...)
+
+	// Results keep the order of the paths however many workers there are
+	input := inputReader{format: "auto", encoding: "auto"}
+	for _, workers := range []int{0, 1, 4, 32} {
+		results := analyzeFiles(paths, workers, input, false, nil)
+		assert.Equal(t, len(paths), len(results))
+		for i, result := range results {
+			assert.Equal(t, paths[i], result.Path)
+			if result.Path == missing {
+				// A missing file is reported without stopping the others
+				assert.True(t, errors.Is(result.Err, fs.ErrNotExist))
+				continue
+			}
+			assert.Nil(t, result.Err, result.Path)
+			assert.Equal(t, 1, len(result.Histogram), result.Path)
+			assert.Equal(t, 2, result.Histogram[0].Count, result.Path)
+		}
+		assert.Equal(t, 40, mergeResults(results).Total())
+	}
+}
+
+func TestAnalyzeFilesDecoding(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"page.html":  "<html><body><p>Old <b>samurai</b></p></body></html>",
+		"latin1.txt": "caf\xe9 old",
+	})
+	paths, failed := expandInputs([]string{dir})
+	assert.Empty(t, failed)
+
+	results := analyzeFiles(paths, 2, inputReader{format: "auto", encoding: "auto"}, true, nil)
+	for _, result := range results {
+		assert.Nil(t, result.Err, result.Path)
+		assert.NotEmpty(t, result.Signature, result.Path)
+	}
+	assert.Equal(t, map[string]int{"café": 1, "old": 2, "samurai": 1}, mergeResults(results).Map())
+}
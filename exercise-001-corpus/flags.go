@@ -0,0 +1,93 @@
+package main
+
+import (
+	"corpus/corpus"
+	"flag"
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// analysisFlags are the flags that control how text is tokenized and
+// counted. They are shared by all word_count commands.
+type analysisFlags struct {
+	ngramSize    int
+	stopLanguage string
+	stopFile     string
+	stem         bool
+	tokenizer    corpus.WordTokenizer
+	workers      int
+	input        inputReader
+}
+
+// addAnalysisFlags registers the analysis flags on fs
+func addAnalysisFlags(fs *flag.FlagSet) *analysisFlags {
+	f := &analysisFlags{}
+	fs.IntVar(&f.ngramSize, "n", 1, "count phrases of `n` consecutive words instead of single words")
+	fs.StringVar(&f.stopLanguage, "stopwords", "", "leave out the built-in stop words of `language` ("+strings.Join(corpus.StopWordLanguages(), ", ")+")")
+	fs.StringVar(&f.stopFile, "stopfile", "", "leave out the stop words listed in `file`")
+	fs.BoolVar(&f.stem, "stem", false, "count English word stems instead of surface forms")
+	fs.BoolVar(&f.tokenizer.SplitContractions, "split-contractions", false, "split contractions like \"don't\" into separate words")
+	fs.BoolVar(&f.tokenizer.JoinHyphens, "join-hyphens", false, "keep hyphenated compounds like \"e-mail\" as one word")
+	fs.BoolVar(&f.tokenizer.SkipNumbers, "skip-numbers", false, "leave out numbers")
+	fs.BoolVar(&f.tokenizer.KeepCase, "keep-case", false, "don't fold words to lowercase")
+	fs.StringVar(&f.input.format, "input-format", "auto", "read inputs as `format` (auto, "+strings.Join(inputFormats(), ", ")+")")
+	fs.StringVar(&f.input.encoding, "encoding", "auto", "read inputs in character `encoding` (auto, "+strings.Join(inputEncodings(), ", ")+")")
+	fs.IntVar(&f.workers, "workers", runtime.NumCPU(), "analyze up to `n` files at the same time")
+	return f
+}
+
+// inputFormats lists the names of the formats inputs can be read as
+func inputFormats() []string {
+	var names []string
+	for _, format := range corpus.Formats {
+		names = append(names, string(format))
+	}
+	return names
+}
+
+// inputEncodings lists the names of the encodings inputs can be read in
+func inputEncodings() []string {
+	var names []string
+	for _, encoding := range corpus.Encodings {
+		names = append(names, string(encoding))
+	}
+	return names
+}
+
+// options turns the flags into corpus options, loading any stop-word lists
+func (f *analysisFlags) options() ([]corpus.Option, error) {
+	if f.input.format != "auto" && !slices.Contains(inputFormats(), f.input.format) {
+		return nil, fmt.Errorf("unknown input format %q, expected auto or one of %s", f.input.format, strings.Join(inputFormats(), ", "))
+	}
+	if f.input.encoding != "auto" {
+		encoding, err := corpus.ParseEncoding(f.input.encoding)
+		if err != nil {
+			return nil, fmt.Errorf("%w, expected auto or one of %s", err, strings.Join(inputEncodings(), ", "))
+		}
+		f.input.encoding = string(encoding)
+	}
+
+	opts := []corpus.Option{corpus.WithNGrams(f.ngramSize), corpus.WithTokenizer(f.tokenizer)}
+	if f.stem {
+		opts = append(opts, corpus.WithStemming())
+	}
+
+	// Load the requested stop-word lists
+	if f.stopLanguage != "" {
+		stopWords, err := corpus.BuiltinStopWords(f.stopLanguage)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, corpus.WithStopWords(stopWords))
+	}
+	if f.stopFile != "" {
+		stopWords, err := corpus.LoadStopWords(f.stopFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, corpus.WithStopWords(stopWords))
+	}
+	return opts, nil
+}
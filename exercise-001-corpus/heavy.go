@@ -0,0 +1,114 @@
+package main
+
+import (
+	"corpus/corpus"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// sketchFlags configure the approximate counting of -heavy-hitters
+type sketchFlags struct {
+	k       int
+	epsilon float64
+	delta   float64
+	memory  int
+}
+
+// newHeavyHitters builds the counter described by the flags. A memory
+// budget takes precedence over epsilon.
+func (f *sketchFlags) newHeavyHitters() (*corpus.HeavyHitters, error) {
+	if f.memory > 0 {
+		return corpus.NewHeavyHittersForMemory(f.k, f.memory, f.delta)
+	}
+	return corpus.NewHeavyHitters(f.k, f.epsilon, f.delta)
+}
+
+// runHeavyHitters counts all files in one fixed-size sketch and prints the
+// top words with their error bounds. Files are read one after another, so
+// memory stays the same however much text there is.
+func runHeavyHitters(paths []string, input inputReader, sketch *sketchFlags, top int, format string, opts []corpus.Option) []fileResult {
+	hh, err := sketch.newHeavyHitters()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+
+	var failed []fileResult
+	for _, path := range paths {
+		if err := heavyHittersFile(hh, path, input, opts); err != nil {
+			failed = append(failed, fileResult{Path: path, Err: err})
+		}
+	}
+
+	n := -1
+	if top > 0 {
+		n = top
+	}
+	if err := writeHeavyHitters(os.Stdout, format, hh, hh.Top(n)); err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing output:", err)
+		os.Exit(1)
+	}
+	return failed
+}
+
+// heavyHittersFile adds the words of a single file to hh
+func heavyHittersFile(hh *corpus.HeavyHitters, path string, in inputReader, opts []corpus.Option) error {
+	input, err := in.open(path)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	return hh.AddReader(input, opts...)
+}
+
+// writeHeavyHitters writes the approximate top words in the given format.
+// The table shows the range of every count and ends with the accuracy of
+// the sketch.
+func writeHeavyHitters(w io.Writer, format string, hh *corpus.HeavyHitters, hitters []corpus.HeavyHitter) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(struct {
+			Total   int                  `json:"total"`
+			Epsilon float64              `json:"epsilon"`
+			Delta   float64              `json:"delta"`
+			Bytes   int                  `json:"bytes"`
+			Words   []corpus.HeavyHitter `json:"words"`
+		}{hh.Total(), hh.Sketch().Epsilon(), hh.Sketch().Delta(), hh.Bytes(), hitters})
+
+	case "tsv", "csv":
+		writer := csv.NewWriter(w)
+		if format == "tsv" {
+			writer.Comma = '\t'
+		}
+		writer.Write([]string{"word", "count", "error"})
+		for _, hitter := range hitters {
+			writer.Write([]string{hitter.Word, strconv.Itoa(hitter.Count), strconv.Itoa(hitter.Error)})
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	wordWidth, countWidth := 0, 0
+	for _, hitter := range hitters {
+		wordWidth = max(wordWidth, displayWidth(hitter.Word))
+		countWidth = max(countWidth, len(strconv.Itoa(hitter.Count)))
+	}
+	for _, hitter := range hitters {
+		padding := strings.Repeat(" ", wordWidth-displayWidth(hitter.Word))
+		_, err := fmt.Fprintf(w, "%s%s  %*d  (at least %d)\n", hitter.Word, padding, countWidth, hitter.Count, hitter.Count-hitter.Error)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "\n%d words counted in %d bytes; sketch estimates exceed the true count by at most %.4g%% of the total with probability %.4g\n",
+		hh.Total(), hh.Bytes(), hh.Sketch().Epsilon()*100, 1-hh.Sketch().Delta())
+	return err
+}
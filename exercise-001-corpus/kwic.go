@@ -0,0 +1,115 @@
+package main
+
+import (
+	"corpus/corpus"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// kwicResult holds the concordance lines found in one file
+type kwicResult struct {
+	Path  string                   `json:"file"`
+	Lines []corpus.ConcordanceLine `json:"lines"`
+}
+
+// runKWIC prints every occurrence of word in the given files with context
+// words on either side. Lines and offsets are those of the decoded text;
+// source offsets point into the file, except for markup and compressed
+// files.
+func runKWIC(paths []string, input inputReader, word string, context int, format string, opts []corpus.Option) []fileResult {
+	var results []kwicResult
+	var failed []fileResult
+
+	for _, path := range paths {
+		lines, err := concordanceFile(path, input, word, context, opts)
+		if err != nil {
+			failed = append(failed, fileResult{Path: path, Err: err})
+			continue
+		}
+		results = append(results, kwicResult{Path: path, Lines: lines})
+	}
+
+	if err := writeKWIC(os.Stdout, format, results); err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing output:", err)
+		os.Exit(1)
+	}
+	return failed
+}
+
+// concordanceFile finds the occurrences of word in a single file
+func concordanceFile(path string, in inputReader, word string, context int, opts []corpus.Option) ([]corpus.ConcordanceLine, error) {
+	input, err := in.open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer input.Close()
+
+	return corpus.Concordance(input, word, context, opts...)
+}
+
+// writeKWIC writes the concordance lines in the given format. The table
+// format lines up the keywords in one column.
+func writeKWIC(w io.Writer, format string, results []kwicResult) error {
+	switch format {
+	case "json":
+		for i := range results {
+			if results[i].Lines == nil {
+				results[i].Lines = []corpus.ConcordanceLine{}
+			}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+
+	case "tsv", "csv":
+		writer := csv.NewWriter(w)
+		if format == "tsv" {
+			writer.Comma = '\t'
+		}
+		writer.Write([]string{"file", "line", "offset", "sourceOffset", "left", "word", "right"})
+		for _, result := range results {
+			for _, line := range result.Lines {
+				writer.Write([]string{
+					result.Path, strconv.Itoa(line.Line), strconv.FormatInt(line.Offset, 10), strconv.FormatInt(line.SourceOffset, 10),
+					strings.Join(line.Left, " "), line.Word, strings.Join(line.Right, " "),
+				})
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	// Size the location and left context columns over all lines
+	locationWidth, leftWidth := 0, 0
+	for _, result := range results {
+		for _, line := range result.Lines {
+			locationWidth = max(locationWidth, len(kwicLocation(result.Path, line)))
+			leftWidth = max(leftWidth, displayWidth(strings.Join(line.Left, " ")))
+		}
+	}
+
+	for _, result := range results {
+		for _, line := range result.Lines {
+			left := strings.Join(line.Left, " ")
+			_, err := fmt.Fprintf(w, "%-*s  %s%s  [%s]  %s\n",
+				locationWidth, kwicLocation(result.Path, line),
+				strings.Repeat(" ", leftWidth-displayWidth(left)), left,
+				line.Word, strings.Join(line.Right, " "))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// kwicLocation formats the position of a line as file:line:offset, counted
+// in the decoded text of the file
+func kwicLocation(path string, line corpus.ConcordanceLine) string {
+	return fmt.Sprintf("%s:%d:%d", path, line.Line, line.Offset)
+}
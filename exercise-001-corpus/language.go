@@ -0,0 +1,94 @@
+package main
+
+import (
+	"corpus/corpus"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// languageResult is the detected language of one file
+type languageResult struct {
+	Path  string               `json:"file"`
+	Guess corpus.LanguageGuess `json:"guess"`
+}
+
+// runLanguages prints the detected language of every file, for example to
+// pick the -stopwords list to analyze it with
+func runLanguages(paths []string, input inputReader, format string) []fileResult {
+	var results []languageResult
+	var failed []fileResult
+
+	for _, path := range paths {
+		guess, err := languageFile(path, input)
+		if err != nil {
+			failed = append(failed, fileResult{Path: path, Err: err})
+			continue
+		}
+		results = append(results, languageResult{Path: path, Guess: guess})
+	}
+
+	if err := writeLanguages(os.Stdout, format, results); err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing output:", err)
+		os.Exit(1)
+	}
+	return failed
+}
+
+// languageFile detects the language of a single file
+func languageFile(path string, in inputReader) (corpus.LanguageGuess, error) {
+	input, err := in.open(path)
+	if err != nil {
+		return corpus.LanguageGuess{}, err
+	}
+	defer input.Close()
+
+	return corpus.DetectLanguageReader(input)
+}
+
+// writeLanguages writes the detected languages in the given format. Files
+// without letters have an empty language, shown as "-" in the table.
+func writeLanguages(w io.Writer, format string, results []languageResult) error {
+	switch format {
+	case "json":
+		if results == nil {
+			results = []languageResult{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+
+	case "tsv", "csv":
+		writer := csv.NewWriter(w)
+		if format == "tsv" {
+			writer.Comma = '\t'
+		}
+		writer.Write([]string{"file", "language", "confidence"})
+		for _, result := range results {
+			writer.Write([]string{result.Path, result.Guess.Language, strconv.FormatFloat(result.Guess.Confidence, 'f', 4, 64)})
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	pathWidth := 0
+	for _, result := range results {
+		pathWidth = max(pathWidth, displayWidth(result.Path))
+	}
+	for _, result := range results {
+		language := result.Guess.Language
+		if language == "" {
+			language = "-"
+		}
+		padding := strings.Repeat(" ", pathWidth-displayWidth(result.Path))
+		_, err := fmt.Fprintf(w, "%s%s  %-2s  %.2f\n", result.Path, padding, language, result.Guess.Confidence)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"corpus/corpus"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// scoreResult is the lexicon score of one file
+type scoreResult struct {
+	Path  string               `json:"file"`
+	Score corpus.DocumentScore `json:"score"`
+}
+
+// runScores prints the score of every file in every category of the
+// lexicon, for example to classify support tickets
+func runScores(paths []string, input inputReader, lexicon corpus.Lexicon, format string, opts []corpus.Option) []fileResult {
+	var results []scoreResult
+	var failed []fileResult
+
+	for _, path := range paths {
+		score, err := scoreFile(path, input, lexicon, opts)
+		if err != nil {
+			failed = append(failed, fileResult{Path: path, Err: err})
+			continue
+		}
+		results = append(results, scoreResult{Path: path, Score: score})
+	}
+
+	if err := writeScores(os.Stdout, format, results); err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing output:", err)
+		os.Exit(1)
+	}
+	return failed
+}
+
+// scoreFile scores a single file
+func scoreFile(path string, in inputReader, lexicon corpus.Lexicon, opts []corpus.Option) (corpus.DocumentScore, error) {
+	input, err := in.open(path)
+	if err != nil {
+		return corpus.DocumentScore{}, err
+	}
+	defer input.Close()
+
+	return lexicon.Score(input, opts...)
+}
+
+// formatWeight formats a score without needless decimals
+func formatWeight(score float64) string {
+	return strconv.FormatFloat(score, 'f', -1, 64)
+}
+
+// writeScores writes the scores in the given format. TSV and CSV have a
+// row for every contributing word, with the score of its category.
+func writeScores(w io.Writer, format string, results []scoreResult) error {
+	switch format {
+	case "json":
+		if results == nil {
+			results = []scoreResult{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+
+	case "tsv", "csv":
+		writer := csv.NewWriter(w)
+		if format == "tsv" {
+			writer.Comma = '\t'
+		}
+		writer.Write([]string{"file", "category", "score", "word", "count", "negated", "wordScore"})
+		for _, result := range results {
+			for _, category := range result.Score.Categories {
+				for _, word := range category.Words {
+					writer.Write([]string{
+						result.Path, category.Category, formatWeight(category.Score),
+						word.Word, strconv.Itoa(word.Count), strconv.Itoa(word.Negated), formatWeight(word.Score),
+					})
+				}
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	for i, result := range results {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "==> %s <== (%d words)\n", result.Path, result.Score.Words)
+		for _, category := range result.Score.Categories {
+			var words []string
+			for _, word := range category.Words {
+				contribution := fmt.Sprintf("%s %+g", word.Word, word.Score)
+				if word.Negated > 0 {
+					contribution += fmt.Sprintf(" (%d negated)", word.Negated)
+				}
+				words = append(words, contribution)
+			}
+			_, err := fmt.Fprintf(w, "%-12s %8s  %s\n", category.Category, formatWeight(category.Score), strings.Join(words, ", "))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
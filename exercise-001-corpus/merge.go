@@ -0,0 +1,60 @@
+package main
+
+import (
+	"corpus/corpus"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// mergeMain runs "word_count merge a.snap b.snap", which adds up histogram
+// snapshots, for example ones produced on different machines
+func mergeMain(args []string) {
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	output := fs.String("o", "", "save the merged histogram as a snapshot in `file` instead of printing it")
+	format := fs.String("format", "table", "output `format` ("+strings.Join(outputFormats, ", ")+")")
+	top := fs.Int("top", 0, "only print the `n` most frequent words (0 prints all)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: word_count merge [flags] <snapshot>...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return
+	}
+	if !slices.Contains(outputFormats, *format) {
+		fatal(fmt.Sprintf("Unknown format %q, expected one of %s", *format, strings.Join(outputFormats, ", ")))
+	}
+
+	// A corrupt snapshot fails the whole merge rather than being left out
+	var histograms []corpus.Histogram
+	for _, path := range fs.Args() {
+		histogram, err := corpus.LoadHistogram(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error loading snapshot:", err)
+			os.Exit(1)
+		}
+		histograms = append(histograms, histogram)
+	}
+	merged := corpus.Histogram{}.Merge(histograms...)
+
+	if *output != "" {
+		if err := corpus.SaveHistogram(*output, merged); err != nil {
+			fmt.Fprintln(os.Stderr, "Error saving snapshot:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *top > 0 {
+		merged = merged.TopN(*top)
+	}
+	if err := writeSections(os.Stdout, *format, []section{{Name: "total", Histogram: merged}}); err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing output:", err)
+		os.Exit(1)
+	}
+}
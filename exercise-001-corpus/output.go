@@ -0,0 +1,142 @@
+package main
+
+import (
+	"corpus/corpus"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// outputFormats lists the values accepted by the -format flag
+var outputFormats = []string{"table", "tsv", "csv", "json"}
+
+// section is one histogram of the output, either for a file or the total
+type section struct {
+	Name      string           `json:"file"`
+	Histogram corpus.Histogram `json:"words"`
+}
+
+// writeSections writes the sections to w in the given format. The name of a
+// section is only printed when there is more than one.
+func writeSections(w io.Writer, format string, sections []section) error {
+	switch format {
+	case "table":
+		return writeTable(w, sections)
+	case "tsv", "csv":
+		return writeDelimited(w, format, sections)
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if len(sections) == 1 {
+			return encoder.Encode(nonNil(sections[0].Histogram))
+		}
+		for i := range sections {
+			sections[i].Histogram = nonNil(sections[i].Histogram)
+		}
+		return encoder.Encode(sections)
+	}
+	return fmt.Errorf("unknown format %q, expected one of %s", format, strings.Join(outputFormats, ", "))
+}
+
+// nonNil makes sure an empty histogram is encoded as [] rather than null
+func nonNil(histogram corpus.Histogram) corpus.Histogram {
+	if histogram == nil {
+		return corpus.Histogram{}
+	}
+	return histogram
+}
+
+// writeTable writes aligned columns, padding words by their display width
+func writeTable(w io.Writer, sections []section) error {
+	for i, section := range sections {
+		if len(sections) > 1 {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "==> %s <==\n", section.Name)
+		}
+
+		// Size the columns to the widest word and count of this section
+		wordWidth, countWidth := 0, 0
+		for _, keyVal := range section.Histogram {
+			wordWidth = max(wordWidth, displayWidth(keyVal.Word))
+			countWidth = max(countWidth, len(strconv.Itoa(keyVal.Count)))
+		}
+
+		for _, keyVal := range section.Histogram {
+			padding := strings.Repeat(" ", wordWidth-displayWidth(keyVal.Word))
+			_, err := fmt.Fprintf(w, "%s%s  %*d\n", keyVal.Word, padding, countWidth, keyVal.Count)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeDelimited writes TSV or CSV with a header row. A file column is
+// added when there is more than one section.
+func writeDelimited(w io.Writer, format string, sections []section) error {
+	writer := csv.NewWriter(w)
+	if format == "tsv" {
+		writer.Comma = '\t'
+	}
+
+	header := []string{"word", "count"}
+	if len(sections) > 1 {
+		header = append([]string{"file"}, header...)
+	}
+	writer.Write(header)
+
+	for _, section := range sections {
+		for _, keyVal := range section.Histogram {
+			record := []string{keyVal.Word, strconv.Itoa(keyVal.Count)}
+			if len(sections) > 1 {
+				record = append([]string{section.Name}, record...)
+			}
+			writer.Write(record)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// wideRanges are the East Asian Wide and Fullwidth ranges, plus the emoji
+// blocks, which take two columns in a terminal
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F}, {0x231A, 0x231B}, {0x2329, 0x232A}, {0x2E80, 0x303E},
+	{0x3041, 0x33FF}, {0x3400, 0x4DBF}, {0x4E00, 0x9FFF}, {0xA000, 0xA4CF},
+	{0xA960, 0xA97F}, {0xAC00, 0xD7A3}, {0xF900, 0xFAFF}, {0xFE10, 0xFE19},
+	{0xFE30, 0xFE6F}, {0xFF00, 0xFF60}, {0xFFE0, 0xFFE6}, {0x1F300, 0x1F64F},
+	{0x1F900, 0x1F9FF}, {0x20000, 0x2FFFD}, {0x30000, 0x3FFFD},
+}
+
+// displayWidth returns the number of terminal columns needed to print s
+func displayWidth(s string) int {
+	width := 0
+	for _, c := range s {
+		width += runeWidth(c)
+	}
+	return width
+}
+
+// runeWidth returns 0 for combining and format characters, 2 for wide
+// characters and 1 for everything else
+func runeWidth(c rune) int {
+	if unicode.In(c, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	for _, r := range wideRanges {
+		if c < r[0] {
+			break
+		}
+		if c <= r[1] {
+			return 2
+		}
+	}
+	return 1
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"corpus/corpus"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	cases := []struct {
+		text  string
+		width int
+	}{
+		{"", 0},
+		{"word", 4},
+		{"\u00fcber", 4},                  // precomposed ü
+		{"cafe\u0301", 4},                 // e + combining acute accent
+		{"\u4e1c\u4eac", 4},               // CJK ideographs
+		{"\uff57\uff4f\uff52\uff44", 8},   // fullwidth Latin
+		{"\ud55c\uad6d\uc5b4", 6},         // Hangul syllables
+		{"\U0001f363", 2},                 // emoji
+		{"a\u200bb", 2},                   // zero-width space
+		{"\u6771\u4eac\u3099x", 5},        // combining kana voicing mark
+		{"no\u0308e\u0301l\U0001f384", 6}, // combining marks and emoji
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.width, displayWidth(c.text), "%q", c.text)
+	}
+}
+
+func TestWriteTableAlignment(t *testing.T) {
+	histogram := corpus.Histogram{
+		{Word: "the", Count: 1200},
+		{Word: "\u4e1c\u4eac", Count: 35},
+		{Word: "cafe\u0301", Count: 7},
+		{Word: "\U0001f363", Count: 7},
+		{Word: "\uff57\uff4f\uff52\uff44", Count: 3},
+	}
+
+	var out strings.Builder
+	assert.Nil(t, writeTable(&out, []section{{Name: "total", Histogram: histogram}}))
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	assert.Equal(t, len(histogram), len(lines))
+
+	// Every count ends in the same column, and starts after the widest word
+	for i, line := range lines {
+		assert.Equal(t, displayWidth(lines[0]), displayWidth(line), "%q", line)
+		word := histogram[i].Word
+		assert.True(t, strings.HasPrefix(line, word), "%q", line)
+		assert.Equal(t, 8+2+4, displayWidth(line), "%q", line)
+	}
+	assert.Equal(t, "\uff57\uff4f\uff52\uff44"+strings.Repeat(" ", 2+3)+"3", lines[4])
+	assert.Equal(t, "cafe\u0301"+strings.Repeat(" ", 4+2+3)+"7", lines[2])
+}
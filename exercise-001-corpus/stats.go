@@ -0,0 +1,123 @@
+package main
+
+import (
+	"corpus/corpus"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// statsSection is the readability report of a file or of all files
+type statsSection struct {
+	Name  string       `json:"file"`
+	Stats corpus.Stats `json:"stats"`
+}
+
+// runStats prints the readability report of all files together, and of
+// every file on its own with perFile. Every file is streamed once, and
+// only counts toward the total if it was read completely.
+func runStats(paths []string, input inputReader, perFile bool, format string, opts []corpus.Option) []fileResult {
+	var sections []statsSection
+	var failed []fileResult
+
+	total := corpus.NewStatsCounter(opts...)
+	for _, path := range paths {
+		stats, err := statsFile(path, input, total)
+		if err != nil {
+			failed = append(failed, fileResult{Path: path, Err: err})
+			continue
+		}
+		if perFile {
+			sections = append(sections, statsSection{Name: path, Stats: stats})
+		}
+	}
+
+	sections = append(sections, statsSection{Name: "total", Stats: total.Stats()})
+	if err := writeStats(os.Stdout, format, sections); err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing output:", err)
+		os.Exit(1)
+	}
+	return failed
+}
+
+// statsFile adds a single file to the total and returns its own report
+func statsFile(path string, in inputReader, total *corpus.StatsCounter) (corpus.Stats, error) {
+	input, err := in.open(path)
+	if err != nil {
+		return corpus.Stats{}, err
+	}
+	defer input.Close()
+	return total.AddReader(input)
+}
+
+// statsRows lists the fields of a report as name and formatted value
+func statsRows(stats corpus.Stats) [][2]string {
+	float := func(f float64) string {
+		return strconv.FormatFloat(f, 'f', 2, 64)
+	}
+	return [][2]string{
+		{"sentences", strconv.Itoa(stats.Sentences)},
+		{"words", strconv.Itoa(stats.Words)},
+		{"types", strconv.Itoa(stats.Types)},
+		{"syllables", strconv.Itoa(stats.Syllables)},
+		{"avgSentenceLength", float(stats.AvgSentenceLength)},
+		{"avgSyllablesPerWord", float(stats.AvgSyllablesPerWord)},
+		{"fleschReadingEase", float(stats.FleschReadingEase)},
+		{"fleschKincaidGrade", float(stats.FleschKincaidGrade)},
+		{"typeTokenRatio", strconv.FormatFloat(stats.TypeTokenRatio, 'f', 4, 64)},
+		{"hapaxLegomena", strconv.Itoa(stats.HapaxLegomena)},
+		{"hapaxDislegomena", strconv.Itoa(stats.HapaxDislegomena)},
+	}
+}
+
+// writeStats writes the reports in the given format. The name of a section
+// is only printed when there is more than one.
+func writeStats(w io.Writer, format string, sections []statsSection) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if len(sections) == 1 {
+			return encoder.Encode(sections[0].Stats)
+		}
+		return encoder.Encode(sections)
+
+	case "tsv", "csv":
+		writer := csv.NewWriter(w)
+		if format == "tsv" {
+			writer.Comma = '\t'
+		}
+		header := []string{"file"}
+		for _, row := range statsRows(corpus.Stats{}) {
+			header = append(header, row[0])
+		}
+		writer.Write(header)
+		for _, section := range sections {
+			record := []string{section.Name}
+			for _, row := range statsRows(section.Stats) {
+				record = append(record, row[1])
+			}
+			writer.Write(record)
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	for i, section := range sections {
+		if len(sections) > 1 {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "==> %s <==\n", section.Name)
+		}
+		for _, row := range statsRows(section.Stats) {
+			if _, err := fmt.Fprintf(w, "%-20s %10s\n", row[0], row[1]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
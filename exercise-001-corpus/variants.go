@@ -0,0 +1,64 @@
+package main
+
+import (
+	"corpus/corpus"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// variantFlags configure the merging of spelling variants
+type variantFlags struct {
+	maxDistance int
+	minRatio    float64
+	log         string
+}
+
+// check rejects settings that would merge real words into each other, so
+// they fail before any file is read
+func (f *variantFlags) check() error {
+	if f.maxDistance < 0 {
+		return fmt.Errorf("-variants must be at least 0, got %d", f.maxDistance)
+	}
+	if f.minRatio < 1 {
+		return fmt.Errorf("-variant-ratio must be at least 1, got %g", f.minRatio)
+	}
+	return nil
+}
+
+// groupVariants merges the spelling variants of total and reports every
+// merge, to the log file if one is given and otherwise to stderr
+func (f *variantFlags) groupVariants(total corpus.Histogram) (corpus.Histogram, error) {
+	grouped, merges := total.GroupVariants(f.maxDistance, f.minRatio)
+	if f.log == "" {
+		reportVariants(os.Stderr, merges)
+		return grouped, nil
+	}
+	return grouped, writeDataFile(f.log, func(w io.Writer) error {
+		return writeVariantLog(w, merges)
+	})
+}
+
+// reportVariants lists the merges made with -variants
+func reportVariants(w io.Writer, merges []corpus.VariantMerge) {
+	if len(merges) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Merged %d spelling variant(s):\n", len(merges))
+	for _, merge := range merges {
+		fmt.Fprintf(w, "  %s (%d) -> %s, distance %d\n", merge.Variant, merge.Count, merge.Into, merge.Distance)
+	}
+}
+
+// writeVariantLog writes the merges as CSV
+func writeVariantLog(w io.Writer, merges []corpus.VariantMerge) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"variant", "into", "count", "distance"})
+	for _, merge := range merges {
+		writer.Write([]string{merge.Variant, merge.Into, strconv.Itoa(merge.Count), strconv.Itoa(merge.Distance)})
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"corpus/corpus"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVariantFlagsCheck(t *testing.T) {
+	assert.Nil(t, (&variantFlags{maxDistance: 2, minRatio: 10}).check())
+	assert.Nil(t, (&variantFlags{maxDistance: 0, minRatio: 1}).check())
+	assert.NotNil(t, (&variantFlags{maxDistance: -1, minRatio: 10}).check())
+	assert.NotNil(t, (&variantFlags{maxDistance: 2, minRatio: 0}).check())
+	assert.NotNil(t, (&variantFlags{maxDistance: 2, minRatio: -3}).check())
+}
+
+func TestGroupVariantsLog(t *testing.T) {
+	total := corpus.Histogram{
+		{Word: "samurai", Count: 30},
+		{Word: "them", Count: 20},
+		{Word: "then", Count: 3},
+		{Word: "samural", Count: 2},
+	}
+	log := filepath.Join(t.TempDir(), "variants.csv")
+	flags := &variantFlags{maxDistance: 2, minRatio: 10, log: log}
+
+	grouped, err := flags.groupVariants(total)
+	assert.Nil(t, err)
+	assert.Equal(t, corpus.Histogram{
+		{Word: "samurai", Count: 32},
+		{Word: "them", Count: 20},
+		{Word: "then", Count: 3},
+	}, grouped)
+
+	// "then" is not rare enough next to "them", so only one merge is logged
+	content, err := os.ReadFile(log)
+	assert.Nil(t, err)
+	assert.Equal(t, "variant,into,count,distance\nsamural,samurai,2,1\n", string(content))
+
+	var stderr strings.Builder
+	reportVariants(&stderr, []corpus.VariantMerge{{Variant: "samural", Into: "samurai", Count: 2, Distance: 1}})
+	assert.Equal(t, "Merged 1 spelling variant(s):\n  samural (2) -> samurai, distance 1\n", stderr.String())
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"corpus/corpus"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// maxLineBytes bounds the memory used for one line. Longer runs of text
+// without a newline are passed on in pieces of at most this size.
+const maxLineBytes = 1 << 20
+
+// watchFlags configure the -follow mode
+type watchFlags struct {
+	follow      bool
+	windowLines int
+	windowAge   time.Duration
+	interval    time.Duration
+}
+
+// lineSource delivers the lines appended to an input since the last poll
+type lineSource interface {
+	poll(fn func(line string)) error
+	Close() error
+}
+
+// checkInput rejects the decoding flags -follow can't honour. Lines are
+// passed on as they are appended, which only works for plain UTF-8 text.
+func (w *watchFlags) checkInput(input inputReader) error {
+	if input.format != "auto" && input.format != string(corpus.FormatText) {
+		return fmt.Errorf("-follow only reads plain text, not -input-format %s", input.format)
+	}
+	if input.encoding != "auto" && input.encoding != string(corpus.EncodingUTF8) {
+		return fmt.Errorf("-follow only reads UTF-8, not -encoding %s", input.encoding)
+	}
+	return nil
+}
+
+// runWatch follows a growing file, or stdin for "-", and redraws the top
+// words every interval until interrupted
+func runWatch(path string, watch *watchFlags, top int, format string, opts []corpus.Option) error {
+	var source lineSource
+	if path == "-" {
+		source = newStdinLines(os.Stdin)
+	} else {
+		// Lines already in the file weren't appended within the window, so
+		// with one only new lines are read, like "tail -f" does
+		follower, err := openFollower(path, watch.windowAge > 0)
+		if err != nil {
+			return err
+		}
+		source = follower
+	}
+	defer source.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	counter := corpus.NewWindowedCounter(watch.windowLines, watch.windowAge, opts...)
+	terminal := isTerminal(os.Stdout)
+	ticker := time.NewTicker(max(watch.interval, 10*time.Millisecond))
+	defer ticker.Stop()
+
+	first := true
+	for {
+		// Every line read in one poll gets the same time; that is accurate
+		// to the refresh interval
+		now := time.Now()
+		changed := first
+		err := source.poll(func(line string) {
+			counter.AddLine(line, now)
+			changed = true
+		})
+		if err != nil {
+			return err
+		}
+		before := counter.Lines()
+		counter.Expire(now)
+		changed = changed || counter.Lines() != before
+
+		if changed {
+			if err := drawWatch(os.Stdout, terminal, path, counter, top, format, now); err != nil {
+				return err
+			}
+		}
+		first = false
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// drawWatch prints the current top words. A terminal is cleared first so
+// the display updates in place; otherwise every update is appended.
+func drawWatch(w io.Writer, terminal bool, path string, counter *corpus.WindowedCounter, top int, format string, now time.Time) error {
+	histogram := counter.Histogram()
+	if top > 0 {
+		histogram = histogram.TopN(top)
+	}
+
+	if format == "table" {
+		if terminal {
+			fmt.Fprint(w, "\033[H\033[2J")
+		}
+		fmt.Fprintf(w, "==> %s at %s <==\n", path, now.Format(time.TimeOnly))
+	}
+	return writeSections(w, format, []section{{Name: path, Histogram: histogram}})
+}
+
+// isTerminal reports whether f is a character device such as a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// follower reads the lines appended to a file like "tail -F". When the file
+// is replaced, for example by log rotation, the rest of the old file is read
+// before the new one is followed from its start. When it is truncated, it
+// is read again from its start. Lines are only passed on once complete.
+type follower struct {
+	path    string
+	file    *os.File
+	info    fs.FileInfo // of the open file, to recognize a replacement
+	offset  int64       // bytes read from the open file
+	partial []byte      // the last line read, if it has no newline yet
+	skip    bool        // the rest of a line is left out, having started in it
+}
+
+// openFollower starts following the file at path from its beginning, or
+// from its end with fromEnd
+func openFollower(path string, fromEnd bool) (*follower, error) {
+	f := &follower{path: path}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	if fromEnd && f.info.Size() > 0 {
+		if err := f.seekEnd(); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// seekEnd skips what the open file holds so far. A line it ends in the
+// middle of is left out when it is completed.
+func (f *follower) seekEnd() error {
+	last := make([]byte, 1)
+	if _, err := f.file.ReadAt(last, f.info.Size()-1); err != nil {
+		return err
+	}
+	offset, err := f.file.Seek(f.info.Size(), io.SeekStart)
+	if err != nil {
+		return err
+	}
+	f.offset, f.skip = offset, last[0] != '\n'
+	return nil
+}
+
+// open opens the file currently at the path
+func (f *follower) open() error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file, f.info, f.offset, f.partial, f.skip = file, info, 0, nil, false
+	return nil
+}
+
+// poll passes every line completed since the last poll to fn
+func (f *follower) poll(fn func(line string)) error {
+	if err := f.readAvailable(fn); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		// Between moving the old file away and creating the new one
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case !os.SameFile(info, f.info):
+		// The old file has been read to its end; its last line is complete
+		if len(f.partial) > 0 {
+			fn(string(f.partial))
+		}
+		f.file.Close()
+		if err := f.open(); err != nil {
+			return err
+		}
+		return f.readAvailable(fn)
+
+	case info.Size() < f.offset:
+		// Truncated in place: what was read before is gone, not repeated
+		if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		f.offset, f.partial, f.skip = 0, nil, false
+		return f.readAvailable(fn)
+	}
+	return nil
+}
+
+// readAvailable reads the open file up to its current end
+func (f *follower) readAvailable(fn func(line string)) error {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := f.file.Read(buf)
+		f.offset += int64(n)
+		data := buf[:n]
+		if f.skip {
+			if i := bytes.IndexByte(data, '\n'); i >= 0 {
+				data, f.skip = data[i+1:], false
+			} else {
+				data = nil
+			}
+		}
+		f.partial = splitLines(f.partial, data, fn)
+
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// splitLines passes every line completed by data to fn and returns the
+// rest, which starts the next line. A line longer than maxLineBytes is
+// passed on in pieces, split at a space where possible.
+func splitLines(partial, data []byte, fn func(line string)) []byte {
+	data = append(partial, data...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		fn(string(data[:i]))
+		data = data[i+1:]
+	}
+	for len(data) >= maxLineBytes {
+		i := bytes.LastIndexByte(data[:maxLineBytes], ' ')
+		if i <= 0 {
+			i = maxLineBytes
+		}
+		fn(string(data[:i]))
+		data = data[i:]
+	}
+	return append([]byte(nil), data...)
+}
+
+// Close closes the followed file
+func (f *follower) Close() error {
+	return f.file.Close()
+}
+
+// stdinLines collects the lines of stdin in the background, since reading
+// a pipe blocks until more is written
+type stdinLines struct {
+	lines chan string
+	err   chan error
+}
+
+// newStdinLines starts reading the lines of r, which is stdin but for tests
+func newStdinLines(r io.Reader) *stdinLines {
+	s := &stdinLines{lines: make(chan string, 1024), err: make(chan error, 1)}
+	go func() {
+		var partial []byte
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := r.Read(buf)
+			partial = splitLines(partial, buf[:n], func(line string) {
+				s.lines <- line
+			})
+			if err != nil {
+				// The last line is complete once stdin is closed
+				if len(partial) > 0 {
+					s.lines <- string(partial)
+				}
+				if err != io.EOF {
+					s.err <- err
+				}
+				close(s.lines)
+				return
+			}
+		}
+	}()
+	return s
+}
+
+// poll passes the lines read since the last poll to fn. Every line read
+// before an error is passed on before the error is returned. Once stdin is
+// closed, the counts stay on display until interrupted.
+func (s *stdinLines) poll(fn func(line string)) error {
+	for {
+		select {
+		case line, ok := <-s.lines:
+			if !ok {
+				select {
+				case err := <-s.err:
+					return err
+				default:
+					return nil
+				}
+			}
+			fn(line)
+		default:
+			return nil
+		}
+	}
+}
+
+// Close implements lineSource. The background reader ends with stdin.
+func (s *stdinLines) Close() error {
+	return nil
+}
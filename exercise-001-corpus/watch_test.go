@@ -0,0 +1,156 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// pollLines returns the lines a poll of f passes on
+func pollLines(t *testing.T, f *follower) []string {
+	t.Helper()
+	var lines []string
+	assert.Nil(t, f.poll(func(line string) {
+		lines = append(lines, line)
+	}))
+	return lines
+}
+
+func appendFile(t *testing.T, path, text string) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	assert.Nil(t, err)
+	_, err = file.WriteString(text)
+	assert.Nil(t, err)
+	assert.Nil(t, file.Close())
+}
+
+func TestFollowerAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "one\ntwo\n")
+
+	f, err := openFollower(path, false)
+	assert.Nil(t, err)
+	defer f.Close()
+
+	assert.Equal(t, []string{"one", "two"}, pollLines(t, f))
+	assert.Empty(t, pollLines(t, f))
+
+	// A line is only passed on once it is complete
+	appendFile(t, path, "thr")
+	assert.Empty(t, pollLines(t, f))
+	appendFile(t, path, "ee\nfour\n")
+	assert.Equal(t, []string{"three", "four"}, pollLines(t, f))
+}
+
+func TestFollowerRotate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendFile(t, path, "one\n")
+
+	f, err := openFollower(path, false)
+	assert.Nil(t, err)
+	defer f.Close()
+	assert.Equal(t, []string{"one"}, pollLines(t, f))
+
+	appendFile(t, path, "two\npartial")
+	assert.Nil(t, os.Rename(path, filepath.Join(dir, "app.log.1")))
+	appendFile(t, path, "three\n")
+
+	// The rest of the old file, its unterminated last line, then the new file
+	assert.Equal(t, []string{"two", "partial", "three"}, pollLines(t, f))
+	assert.Empty(t, pollLines(t, f))
+
+	// A poll between moving the old file away and creating the new one
+	appendFile(t, path, "four\nlast")
+	assert.Nil(t, os.Rename(path, filepath.Join(dir, "app.log.2")))
+	assert.Equal(t, []string{"four"}, pollLines(t, f))
+	appendFile(t, path, "five\n")
+	assert.Equal(t, []string{"last", "five"}, pollLines(t, f))
+}
+
+func TestFollowerTruncate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "one\ntwo\n")
+
+	f, err := openFollower(path, false)
+	assert.Nil(t, err)
+	defer f.Close()
+	assert.Equal(t, []string{"one", "two"}, pollLines(t, f))
+
+	// Truncated in place and written again: only the new lines are read
+	assert.Nil(t, os.WriteFile(path, []byte("new\n"), 0o644))
+	assert.Equal(t, []string{"new"}, pollLines(t, f))
+	appendFile(t, path, "more\n")
+	assert.Equal(t, []string{"more"}, pollLines(t, f))
+}
+
+func TestFollowerFromEnd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "old\nlines\nhalf a li")
+
+	// Only lines appended later are read, not the rest of the last one
+	f, err := openFollower(path, true)
+	assert.Nil(t, err)
+	defer f.Close()
+	assert.Empty(t, pollLines(t, f))
+	appendFile(t, path, "ne\nnew\n")
+	assert.Equal(t, []string{"new"}, pollLines(t, f))
+
+	// An empty file is read from its start
+	empty := filepath.Join(t.TempDir(), "empty.log")
+	appendFile(t, empty, "")
+	f, err = openFollower(empty, true)
+	assert.Nil(t, err)
+	defer f.Close()
+	appendFile(t, empty, "first\n")
+	assert.Equal(t, []string{"first"}, pollLines(t, f))
+}
+
+func TestFollowerLongLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, strings.Repeat("word ", maxLineBytes/5)+"end")
+
+	f, err := openFollower(path, false)
+	assert.Nil(t, err)
+	defer f.Close()
+
+	// A line without a newline doesn't grow without bounds
+	lines := pollLines(t, f)
+	assert.Equal(t, 1, len(lines))
+	assert.LessOrEqual(t, len(lines[0]), maxLineBytes)
+	assert.True(t, strings.HasSuffix(lines[0], "word"))
+	assert.Less(t, len(f.partial), maxLineBytes)
+}
+
+func TestStdinLinesError(t *testing.T) {
+	errBroken := errors.New("broken pipe")
+	s := newStdinLines(io.MultiReader(strings.NewReader("one\ntwo\n"), iotest.ErrReader(errBroken)))
+
+	// The lines read before the error are passed on first
+	var lines []string
+	var err error
+	for deadline := time.Now().Add(5 * time.Second); err == nil && time.Now().Before(deadline); {
+		err = s.poll(func(line string) {
+			lines = append(lines, line)
+		})
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, errBroken, err)
+	assert.Equal(t, []string{"one", "two"}, lines)
+}
+
+func TestWatchCheckInput(t *testing.T) {
+	watch := &watchFlags{}
+	assert.Nil(t, watch.checkInput(inputReader{format: "auto", encoding: "auto"}))
+	assert.Nil(t, watch.checkInput(inputReader{format: "text", encoding: "utf-8"}))
+	assert.NotNil(t, watch.checkInput(inputReader{format: "html", encoding: "auto"}))
+	assert.NotNil(t, watch.checkInput(inputReader{format: "auto", encoding: "iso-8859-1"}))
+}
@@ -2,35 +2,266 @@ package main
 
 import (
 	"corpus/corpus"
+	"errors"
+	"flag"
 	"fmt"
+	"io/fs"
 	"os"
+	"slices"
+	"strings"
+	"time"
 )
 
 func main() {
-	// Check if the file name is provided as an argument
-	if len(os.Args) < 2 {
-		fmt.Println("Usage: go run main.go <filename>")
-		return
+	// Subcommands are picked by the first argument
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "compare":
+			compareMain(os.Args[2:])
+			return
+		case "merge":
+			mergeMain(os.Args[2:])
+			return
+		}
+	}
+
+	analysis := addAnalysisFlags(flag.CommandLine)
+	perFile := flag.Bool("per-file", false, "print a separate histogram for every file before the merged one")
+	format := flag.String("format", "table", "output `format` ("+strings.Join(outputFormats, ", ")+")")
+	top := flag.Int("top", 0, "only print the `n` most frequent words (0 prints all)")
+	minCount := flag.Int("min-count", 0, "only print words that occur at least `n` times")
+	minLength := flag.Int("min-length", 0, "only print words of at least `n` characters")
+	kwic := flag.String("kwic", "", "print every occurrence of `word` in context instead of counting")
+	context := flag.Int("context", 5, "number of context `words` on either side for -kwic")
+	stats := flag.Bool("stats", false, "print readability and lexical statistics instead of counting")
+	watch := &watchFlags{}
+	flag.BoolVar(&watch.follow, "follow", false, "keep reading a growing file like tail -F and redraw the top words as lines are appended")
+	flag.IntVar(&watch.windowLines, "window-lines", 0, "with -follow, only count the last `n` lines")
+	flag.DurationVar(&watch.windowAge, "window", 0, "with -follow, only count the lines appended within `duration`, e.g. 10m, skipping those already in the file")
+	flag.DurationVar(&watch.interval, "interval", time.Second, "with -follow, check for new lines every `duration`")
+	zipf := flag.Bool("zipf", false, "print the Zipf and Heaps' law fits of the vocabulary instead of counting")
+	zipfData := flag.String("zipf-data", "", "with -zipf, write the rank/frequency data for plotting to `file` as CSV")
+	growthData := flag.String("growth-data", "", "with -zipf, write the vocabulary growth curve to `file` as CSV")
+	lexiconPath := flag.String("lexicon", "", "score every file with the word categories and weights in lexicon `file` instead of counting")
+	language := flag.Bool("language", false, "print the detected language of every file instead of counting")
+	sketch := &sketchFlags{}
+	flag.IntVar(&sketch.k, "heavy-hitters", 0, "count approximately in fixed memory and print the top `k` words with error bounds")
+	flag.Float64Var(&sketch.epsilon, "epsilon", 0.0001, "with -heavy-hitters, the error of a count as a `fraction` of all words")
+	flag.Float64Var(&sketch.delta, "delta", 0.01, "with -heavy-hitters, the `probability` that a count exceeds its error")
+	flag.IntVar(&sketch.memory, "memory", 0, "with -heavy-hitters, fit the counters in about `bytes` instead of using -epsilon")
+	dedupe := &dedupeFlags{}
+	flag.BoolVar(&dedupe.enabled, "dedupe", false, "skip files that are near-duplicates of an earlier file before counting (not with other modes such as -stats)")
+	flag.Float64Var(&dedupe.threshold, "dedupe-threshold", 0.8, "with -dedupe, the estimated Jaccard `similarity` of word shingles from which files are duplicates")
+	variants := &variantFlags{}
+	flag.IntVar(&variants.maxDistance, "variants", 0, "merge rare spelling variants of the total into frequent words within edit distance `n`")
+	flag.Float64Var(&variants.minRatio, "variant-ratio", 10, "with -variants, only merge into words that occur at least `times` as often (at least 1)")
+	flag.StringVar(&variants.log, "variant-log", "", "with -variants, write the merges to `file` as CSV instead of listing them on stderr")
+	snapshot := flag.String("snapshot", "", "add the counts to the histogram snapshot in `file`, creating it if needed, unless a file fails")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: word_count [flags] <file|dir|glob|->...")
+		fmt.Fprintln(flag.CommandLine.Output(), "       word_count compare [flags] <a> <b>")
+		fmt.Fprintln(flag.CommandLine.Output(), "       word_count merge [flags] <snapshot>...")
+		flag.PrintDefaults()
 	}
+	flag.Parse()
 
-	// Get the file name from command-line arguments
-	filename := os.Args[1]
+	// Check if a file name is provided as an argument
+	if flag.NArg() < 1 {
+		flag.Usage()
+		return
+	}
+	if !slices.Contains(outputFormats, *format) {
+		fatal(fmt.Sprintf("Unknown format %q, expected one of %s", *format, strings.Join(outputFormats, ", ")))
+	}
 
-	// Reads the entire file content into a []byte
-	content, err := os.ReadFile(filename)
+	opts, err := analysis.options()
 	if err != nil {
-		fmt.Println("Error reading file:", err)
+		fatal("Error:", err)
+	}
+
+	// Each mode replaces counting, so at most one of them can be chosen
+	var modes []string
+	for _, mode := range []struct {
+		flag string
+		set  bool
+	}{
+		{"-follow", watch.follow},
+		{"-kwic", *kwic != ""},
+		{"-stats", *stats},
+		{"-zipf", *zipf},
+		{"-lexicon", *lexiconPath != ""},
+		{"-language", *language},
+		{"-heavy-hitters", sketch.k > 0},
+	} {
+		if mode.set {
+			modes = append(modes, mode.flag)
+		}
+	}
+	if len(modes) > 1 {
+		fatal(fmt.Sprintf("Only one of %s can be used at a time", strings.Join(modes, ", ")))
+	}
+
+	// Some options change how the counts are made or kept, so no mode uses them
+	if len(modes) > 0 {
+		for _, option := range []struct {
+			flag string
+			set  bool
+		}{
+			{"-dedupe", dedupe.enabled},
+			{"-snapshot", *snapshot != ""},
+			{"-variants", variants.maxDistance != 0},
+		} {
+			if option.set {
+				fatal(fmt.Sprintf("%s only applies to counting and can't be used with %s", option.flag, modes[0]))
+			}
+		}
+	}
+
+	if err := variants.check(); err != nil {
+		fatal("Error:", err)
+	}
+
+	// Near-duplicates are only dropped from the counts
+	var index *corpus.LSHIndex
+	if dedupe.enabled {
+		if index, err = dedupe.newIndex(); err != nil {
+			fatal("Error:", err)
+		}
+	}
+
+	// Follow a single growing file until interrupted
+	if watch.follow {
+		if flag.NArg() != 1 {
+			fatal("-follow needs exactly one file")
+		}
+		if err := watch.checkInput(analysis.input); err != nil {
+			fatal("Error:", err)
+		}
+		if err := runWatch(flag.Arg(0), watch, *top, *format, opts); err != nil {
+			fmt.Fprintln(os.Stderr, "Error following file:", err)
+			os.Exit(1)
+		}
 		return
 	}
 
-	histogram := corpus.Analysis(string(content))
+	// Expand the arguments, "-" reads stdin
+	paths, failed := expandInputs(flag.Args())
 
-	for _, keyVal := range histogram {
-		if len(keyVal.Word) >= 8 {
-			fmt.Printf("%s\t%d\n", keyVal.Word, keyVal.Count)
-		} else {
-			fmt.Printf("%s\t\t%d\n", keyVal.Word, keyVal.Count)
+	// Show the keyword in context instead of a histogram
+	if *kwic != "" {
+		failed = append(failed, runKWIC(paths, analysis.input, *kwic, *context, *format, opts)...)
+		reportFailures(failed)
+		return
+	}
+
+	// Report readability statistics instead of a histogram
+	if *stats {
+		failed = append(failed, runStats(paths, analysis.input, *perFile, *format, opts)...)
+		reportFailures(failed)
+		return
+	}
+
+	// Fit Zipf's and Heaps' laws, e.g. to spot truncated or duplicated input
+	if *zipf {
+		failed = append(failed, runZipf(paths, analysis.input, *perFile, *format, *zipfData, *growthData, opts)...)
+		reportFailures(failed)
+		return
+	}
+
+	// Score against a lexicon, e.g. to classify support tickets
+	if *lexiconPath != "" {
+		lexicon, err := corpus.LoadLexicon(*lexiconPath)
+		if err != nil {
+			fatal("Error loading lexicon:", err)
+		}
+		failed = append(failed, runScores(paths, analysis.input, lexicon, *format, opts)...)
+		reportFailures(failed)
+		return
+	}
+
+	// Identify languages, e.g. to route files to the right stop words
+	if *language {
+		failed = append(failed, runLanguages(paths, analysis.input, *format)...)
+		reportFailures(failed)
+		return
+	}
+
+	// Count in fixed memory instead of keeping every word
+	if sketch.k > 0 {
+		failed = append(failed, runHeavyHitters(paths, analysis.input, sketch, *top, *format, opts)...)
+		reportFailures(failed)
+		return
+	}
+
+	// Stream every file through the analyzer instead of loading it all
+	results := analyzeFiles(paths, analysis.workers, analysis.input, dedupe.enabled, opts)
+
+	// Leave out near-duplicates, keeping the first file of each group
+	if dedupe.enabled {
+		var dropped []duplicateFile
+		results, dropped, err = dropDuplicates(results, index)
+		if err != nil {
+			fatal("Error:", err)
+		}
+		reportDuplicates(os.Stderr, dropped)
+	}
+
+	// Rank and filter the histograms before printing them
+	rank := func(histogram corpus.Histogram) corpus.Histogram {
+		histogram = histogram.Filter(corpus.MinCount(*minCount)).Filter(corpus.MinLength(*minLength))
+		if *top > 0 {
+			histogram = histogram.TopN(*top)
+		}
+		return histogram
+	}
+
+	var sections []section
+	if *perFile {
+		for _, result := range results {
+			if result.Err == nil {
+				sections = append(sections, section{Name: result.Path, Histogram: rank(result.Histogram)})
+			}
 		}
+	}
+	total := mergeResults(results)
 
+	// Unreadable files don't stop the run, but are reported at the end
+	for _, result := range results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
 	}
+
+	// Counts of earlier runs are kept in the snapshot, which is updated. It
+	// is left alone if any file failed, so running again once the input is
+	// fixed doesn't count the other files twice.
+	if *snapshot != "" && len(failed) > 0 {
+		fmt.Fprintf(os.Stderr, "Not updating snapshot %s because %d file(s) failed\n", *snapshot, len(failed))
+	} else if *snapshot != "" {
+		previous, err := corpus.LoadHistogram(*snapshot)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			fmt.Fprintln(os.Stderr, "Error loading snapshot:", err)
+			os.Exit(1)
+		}
+		total = previous.Merge(total)
+		if err := corpus.SaveHistogram(*snapshot, total); err != nil {
+			fmt.Fprintln(os.Stderr, "Error saving snapshot:", err)
+			os.Exit(1)
+		}
+	}
+	// The snapshot keeps the counts as read, variants are only merged for output
+	if variants.maxDistance > 0 {
+		if total, err = variants.groupVariants(total); err != nil {
+			fmt.Fprintln(os.Stderr, "Error writing variant log:", err)
+			os.Exit(1)
+		}
+	}
+	sections = append(sections, section{Name: "total", Histogram: rank(total)})
+
+	if err := writeSections(os.Stdout, *format, sections); err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing output:", err)
+		os.Exit(1)
+	}
+
+	reportFailures(failed)
 }
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"corpus/corpus"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// zipfSection is the vocabulary report of a file or of all files
+type zipfSection struct {
+	Name   string                  `json:"file"`
+	Report corpus.VocabularyReport `json:"report"`
+}
+
+// runZipf prints the Zipf and Heaps fits of all files together, and of
+// every file on its own with perFile. The rank/frequency data and the
+// growth curve of the total are written as CSV to the given files, if any.
+// As with -stats, only files read completely count toward the total.
+func runZipf(paths []string, input inputReader, perFile bool, format, rankData, growthData string, opts []corpus.Option) []fileResult {
+	var sections []zipfSection
+	var failed []fileResult
+
+	counter := corpus.NewVocabularyCounter(opts...)
+	for _, path := range paths {
+		report, err := vocabularyFile(path, input, counter)
+		if err != nil {
+			failed = append(failed, fileResult{Path: path, Err: err})
+			continue
+		}
+		if perFile {
+			sections = append(sections, zipfSection{Name: path, Report: report})
+		}
+	}
+	total := counter.Report()
+
+	for _, export := range []struct {
+		path  string
+		write func(io.Writer) error
+	}{
+		{rankData, total.WriteRankFrequency},
+		{growthData, total.WriteGrowth},
+	} {
+		if export.path == "" {
+			continue
+		}
+		if err := writeDataFile(export.path, export.write); err != nil {
+			fmt.Fprintln(os.Stderr, "Error writing data:", err)
+			os.Exit(1)
+		}
+	}
+
+	sections = append(sections, zipfSection{Name: "total", Report: total})
+	if err := writeZipf(os.Stdout, format, sections); err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing output:", err)
+		os.Exit(1)
+	}
+	return failed
+}
+
+// vocabularyFile adds a single file to the total and returns its own report
+func vocabularyFile(path string, in inputReader, total *corpus.VocabularyCounter) (corpus.VocabularyReport, error) {
+	input, err := in.open(path)
+	if err != nil {
+		return corpus.VocabularyReport{}, err
+	}
+	defer input.Close()
+	return total.AddReader(input)
+}
+
+// writeDataFile writes plotting data to the file at path, "-" for stdout
+func writeDataFile(path string, write func(io.Writer) error) error {
+	if path == "-" {
+		return write(os.Stdout)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// zipfRows lists the fields of a report as name and formatted value
+func zipfRows(report corpus.VocabularyReport) [][2]string {
+	float := func(f float64) string {
+		return strconv.FormatFloat(f, 'f', 4, 64)
+	}
+	return [][2]string{
+		{"tokens", strconv.Itoa(report.Tokens)},
+		{"types", strconv.Itoa(report.Types)},
+		{"zipfExponent", float(report.Zipf.Exponent)},
+		{"zipfC", strconv.FormatFloat(report.Zipf.C, 'f', 2, 64)},
+		{"zipfRSquared", float(report.Zipf.RSquared)},
+		{"heapsK", float(report.Heaps.K)},
+		{"heapsBeta", float(report.Heaps.Beta)},
+		{"heapsRSquared", float(report.Heaps.RSquared)},
+	}
+}
+
+// writeZipf writes the reports in the given format. The growth curve is
+// only included in JSON; the name of a section is only printed when there
+// is more than one.
+func writeZipf(w io.Writer, format string, sections []zipfSection) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if len(sections) == 1 {
+			return encoder.Encode(sections[0].Report)
+		}
+		return encoder.Encode(sections)
+
+	case "tsv", "csv":
+		writer := csv.NewWriter(w)
+		if format == "tsv" {
+			writer.Comma = '\t'
+		}
+		header := []string{"file"}
+		for _, row := range zipfRows(corpus.VocabularyReport{}) {
+			header = append(header, row[0])
+		}
+		writer.Write(header)
+		for _, section := range sections {
+			record := []string{section.Name}
+			for _, row := range zipfRows(section.Report) {
+				record = append(record, row[1])
+			}
+			writer.Write(record)
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	for i, section := range sections {
+		if len(sections) > 1 {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "==> %s <==\n", section.Name)
+		}
+		for _, row := range zipfRows(section.Report) {
+			if _, err := fmt.Fprintf(w, "%-20s %10s\n", row[0], row[1]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}